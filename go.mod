@@ -7,6 +7,7 @@ require (
 	github.com/alexedwards/scs/v2 v2.8.0
 	github.com/aymerick/douceur v0.2.0
 	github.com/go-chi/chi/v5 v5.0.12
+	github.com/go-sql-driver/mysql v1.8.1
 	github.com/golang-jwt/jwt/v5 v5.2.1
 	github.com/gomodule/redigo v1.9.2
 	github.com/jackc/pgconn v1.14.3
@@ -17,10 +18,13 @@ require (
 	github.com/robfig/cron/v3 v3.0.1
 	github.com/xhit/go-simple-mail/v2 v2.16.0
 	golang.org/x/crypto v0.22.0
+	golang.org/x/net v0.21.0
+	google.golang.org/grpc v1.63.2
 	jaytaylor.com/html2text v0.0.0-20230321000545-74c2419ad056
 )
 
 require (
+	filippo.io/edwards25519 v1.1.0 // indirect
 	github.com/PuerkitoBio/goquery v1.9.1 // indirect
 	github.com/andybalholm/cascadia v1.3.2 // indirect
 	github.com/go-test/deep v1.1.0 // indirect
@@ -35,7 +39,8 @@ require (
 	github.com/olekukonko/tablewriter v0.0.5 // indirect
 	github.com/ssor/bom v0.0.0-20170718123548-6386211fdfcf // indirect
 	github.com/toorop/go-dkim v0.0.0-20201103131630-e1cd1a0a5208 // indirect
-	golang.org/x/net v0.21.0 // indirect
 	golang.org/x/sys v0.19.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240227224415-6ceb2ff114de // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
 )
@@ -3,8 +3,14 @@ package certificateutils
 
 import (
 	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/pem"
 	"errors"
 	"fmt"
@@ -16,6 +22,9 @@ import (
 
 var (
 	errHostNameEmpty = errors.New("hostname empty")
+
+	// ErrNoCertificate is returned for a PEM file without a certificate, such as a key or a CSR
+	ErrNoCertificate = errors.New("certificate doesn't have a valid PEM block")
 )
 
 // ResultError holds the result of certificate errors
@@ -36,17 +45,57 @@ type CertificateDetails struct {
 	TimeTaken           time.Duration
 	ExpirationDate      string
 	Thumbprint          string
+	SANs                []string
+	Chain               []string
+	KeyType             string
+	KeySize             int
+	SignatureAlgorithm  string
+	TLSVersion          string
+	SelfSigned          bool
+	ChainVerified       bool
+	ChainError          string
+	HostnameVerified    bool
+	HostnameError       string
+	OCSPStapled         bool
+	RevocationStatus    string
+	RevocationSource    string
+	RevokedAt           time.Time
+	RevocationError     string
+	KeyMatched          bool
+}
+
+// VerifyOptions controls how a certificate fetched from a host is verified
+type VerifyOptions struct {
+	// RootCAs are the trusted roots; nil means the system roots
+	RootCAs *x509.CertPool
+	// ServerName is sent with SNI and matched against the SANs; it defaults
+	// to the hostname without its port
+	ServerName string
+	// CheckOCSP queries the OCSP responder when no stapled response answers
+	CheckOCSP bool
+	// OCSPServer replaces the responder listed in the certificate
+	OCSPServer string
+	// CheckCRL fetches the CRLs of the certificate when OCSP gives no answer
+	CheckCRL bool
+	// CRLURL replaces the distribution points listed in the certificate
+	CRLURL string
 }
 
 // String returns a formatted string response
 func (cd CertificateDetails) String() string {
 	return fmt.Sprintf(
-		"Subject Name: %s\nIssuer: %s\nExpiration date: %s\nDays Until Expiration: %d\nSerial #: %s\nRequest Time: %v\n",
+		"Subject Name: %s\nIssuer: %s\nExpiration date: %s\nDays Until Expiration: %d\nSerial #: %s\nSANs: %s\nKey: %s %d\nSignature: %s\nTLS Version: %s\nRevocation: %s\nRequest Time: %v\n",
 		cd.SubjectName,
 		cd.IssuerName,
 		cd.ExpirationDate,
 		cd.DaysUntilExpiration,
 		cd.SerialNumber,
+		strings.Join(cd.SANs, ", "),
+		cd.KeyType,
+		cd.KeySize,
+		cd.SignatureAlgorithm,
+		cd.TLSVersion,
+		cd.RevocationStatus,
 		cd.TimeTaken,
 	)
 }
@@ -66,60 +115,133 @@ func insertNth(s string, n int) string {
 	return buffer.String()
 }
 
-// ReadCertificateDetailsFromFile reads a cert from disk
+// ReadCertificateDetailsFromFile reads every certificate of a PEM file. The private key
+// is looked up in privateCertFile, or in the certificate file itself when it is empty,
+// and KeyMatched is set on the certificates whose public key it matches
 func ReadCertificateDetailsFromFile(publicCertFile, privateCertFile string) ([]CertificateDetails, error) {
 	currentTime := time.Now()
 	var certDetails []CertificateDetails
-	var blocks []byte
 
-	// change to os.ReadFile
 	rest, err := os.ReadFile(publicCertFile)
 	if err != nil {
 		return certDetails, err
 	}
 
+	var certs []*x509.Certificate
+	var keyBlocks []*pem.Block
+
 	for {
 		var block *pem.Block
 		block, rest = pem.Decode(rest)
-
 		if block == nil {
-			return certDetails, errors.New("certificate doesn't have a valid PEM block")
+			break
 		}
 
-		blocks = append(blocks, block.Bytes...)
-		if len(rest) == 0 {
-			break
+		switch {
+		case block.Type == "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return certDetails, err
+			}
+			certs = append(certs, cert)
+		case strings.HasSuffix(block.Type, "PRIVATE KEY"):
+			keyBlocks = append(keyBlocks, block)
 		}
 	}
 
-	certs, err := x509.ParseCertificates(blocks)
-	if err != nil {
-		return certDetails, err
+	if len(certs) == 0 {
+		return certDetails, ErrNoCertificate
 	}
 
-	for _, cert := range certs {
-		daysUntilExpiration := int(cert.NotAfter.Sub(currentTime).Hours() / 24)
-		subjectName := cert.Subject.Names[len(cert.Subject.Names)-1].Value.(string)
-		issuerName := cert.Issuer.Names[len(cert.Issuer.Names)-1].Value.(string)
-		serialNumber := cert.SerialNumber.Text(16)
-		elapsed := time.Since(currentTime)
+	if privateCertFile != "" {
+		keyPEM, err := os.ReadFile(privateCertFile)
+		if err != nil {
+			return certDetails, err
+		}
+
+		keyBlocks = nil
+		for {
+			var block *pem.Block
+			block, keyPEM = pem.Decode(keyPEM)
+			if block == nil {
+				break
+			}
+			if strings.HasSuffix(block.Type, "PRIVATE KEY") {
+				keyBlocks = append(keyBlocks, block)
+			}
+		}
+
+		if len(keyBlocks) == 0 {
+			return certDetails, fmt.Errorf("no private key found in %s", privateCertFile)
+		}
+	}
 
-		certDetails = append(certDetails, CertificateDetails{
-			DaysUntilExpiration: daysUntilExpiration,
-			SubjectName:         subjectName,
-			IssuerName:          issuerName,
-			SerialNumber:        strings.ToUpper(insertNth(serialNumber, 2)),
-			TimeTaken:           elapsed,
-			ExpirationDate:      cert.NotAfter.Format(time.UnixDate),
-		})
+	var keys []crypto.Signer
+	for _, block := range keyBlocks {
+		key, err := parsePrivateKey(block.Bytes)
+		if err != nil {
+			return certDetails, err
+		}
+		keys = append(keys, key)
+	}
+
+	for _, cert := range certs {
+		cd := detailsFromCertificate(cert, currentTime)
+		cd.Hostname = publicCertFile
+		cd.KeyMatched = publicKeyMatches(cert, keys)
+		cd.TimeTaken = time.Since(currentTime)
 
+		certDetails = append(certDetails, cd)
 	}
 
 	return certDetails, nil
 }
 
-// GetCertificateDetails gets a certificate and its details
+// parsePrivateKey parses a PKCS #1, PKCS #8 or SEC 1 encoded private key
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, errors.New("unsupported or encrypted private key")
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key type")
+	}
+
+	return signer, nil
+}
+
+// publicKeyMatches reports whether one of the private keys belongs to the certificate
+func publicKeyMatches(cert *x509.Certificate, keys []crypto.Signer) bool {
+	for _, key := range keys {
+		public, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+		if ok && public.Equal(cert.PublicKey) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetCertificateDetails gets a certificate and its details, verified against the system roots
 func GetCertificateDetails(hostname string, connectionTimeout int) (CertificateDetails, error) {
+	return GetCertificateDetailsWithOptions(context.Background(), hostname, connectionTimeout, VerifyOptions{})
+}
+
+// GetCertificateDetailsWithOptions gets a certificate and its details. The handshake
+// accepts any certificate so details are returned even for a broken chain; the
+// verification outcome is reported in the ChainVerified and HostnameVerified fields.
+// Revocation queries end with ctx
+func GetCertificateDetailsWithOptions(ctx context.Context, hostname string, connectionTimeout int, opts VerifyOptions) (CertificateDetails, error) {
 	currentTime := time.Now()
 	var certDetails CertificateDetails
 
@@ -131,48 +253,154 @@ func GetCertificateDetails(hostname string, connectionTimeout int) (CertificateD
 		hostname = fmt.Sprintf("%s:443", hostname)
 	}
 
+	serverName := opts.ServerName
+	if serverName == "" {
+		serverName, _, _ = net.SplitHostPort(hostname)
+	}
+
 	// Establish a new TCP connection to hostname
-	conn, err := net.DialTimeout("tcp", hostname, time.Second*time.Duration(connectionTimeout))
+	d := net.Dialer{Timeout: time.Second * time.Duration(connectionTimeout)}
+	conn, err := d.DialContext(ctx, "tcp", hostname)
 	if err != nil {
-		return CertificateDetails{}, fmt.Errorf("connection error: %v", err)
+		return CertificateDetails{}, fmt.Errorf("connection error: %w", err)
 	}
 
-	tlsConn := tls.Client(conn, &tls.Config{InsecureSkipVerify: true})
+	_ = conn.SetDeadline(time.Now().Add(time.Second * time.Duration(connectionTimeout)))
+	tlsConn := tls.Client(conn, &tls.Config{InsecureSkipVerify: true, ServerName: serverName})
 	defer tlsConn.Close()
 
 	if err = tlsConn.Handshake(); err != nil {
-		fmt.Println("TLS handshake error:", err)
+		return CertificateDetails{}, fmt.Errorf("tls handshake error: %w", err)
+	}
+
+	certDetails, err = DetailsFromConnectionState(ctx, tlsConn.ConnectionState(), hostname, serverName, opts, time.Second*time.Duration(connectionTimeout))
+	if err != nil {
 		return CertificateDetails{}, err
 	}
+	certDetails.TimeTaken = time.Since(currentTime)
+
+	return certDetails, nil
+}
 
-	// Loop through each certificate peer and determine certificate details for non-CA certificate
-	for _, cert := range tlsConn.ConnectionState().PeerCertificates {
+// DetailsFromConnectionState gets the details of the certificate presented on a tls
+// connection that is already established, such as one upgraded with STARTTLS
+func DetailsFromConnectionState(ctx context.Context, state tls.ConnectionState, hostname, serverName string, opts VerifyOptions, timeout time.Duration) (CertificateDetails, error) {
+	if len(state.PeerCertificates) == 0 {
+		return CertificateDetails{}, errors.New("no certificate presented")
+	}
 
-		if cert.IsCA {
-			continue
+	// Loop through each certificate peer and determine certificate details for non-CA certificate,
+	// falling back to the first one when a self-signed CA certificate is served directly
+	leaf := state.PeerCertificates[0]
+	for _, cert := range state.PeerCertificates {
+		if !cert.IsCA {
+			leaf = cert
+			break
 		}
+	}
 
-		daysUntilExpiration := int(cert.NotAfter.Sub(currentTime).Hours() / 24)
-		subjectName := cert.Subject.Names[len(cert.Subject.Names)-1].Value.(string)
-		issuerName := cert.Issuer.Names[len(cert.Issuer.Names)-1].Value.(string)
-		serialNumber := cert.SerialNumber.Text(16)
-		elapsed := time.Since(currentTime)
-
-		certDetails = CertificateDetails{
-			DaysUntilExpiration: daysUntilExpiration,
-			SubjectName:         subjectName,
-			IssuerName:          issuerName,
-			SerialNumber:        strings.ToUpper(insertNth(serialNumber, 2)),
-			Hostname:            hostname,
-			TimeTaken:           elapsed,
-			ExpirationDate:      cert.NotAfter.Format(time.UnixDate),
-		}
-		break
+	certDetails := detailsFromCertificate(leaf, time.Now())
+	certDetails.Hostname = hostname
+	certDetails.TLSVersion = tls.VersionName(state.Version)
+	chain := verifyCertificate(&certDetails, leaf, state.PeerCertificates, serverName, opts.RootCAs)
+	if !certDetails.SelfSigned {
+		issuer := issuerOf(leaf, chain, state.PeerCertificates)
+		checkRevocation(ctx, &certDetails, leaf, issuer, state.OCSPResponse, opts, timeout)
 	}
 
 	return certDetails, nil
 }
 
+// detailsFromCertificate fills the details that can be read from the certificate itself
+func detailsFromCertificate(cert *x509.Certificate, currentTime time.Time) CertificateDetails {
+	cd := CertificateDetails{
+		DaysUntilExpiration: int(cert.NotAfter.Sub(currentTime).Hours() / 24),
+		SubjectName:         lastName(cert.Subject.Names),
+		IssuerName:          lastName(cert.Issuer.Names),
+		SerialNumber:        strings.ToUpper(insertNth(cert.SerialNumber.Text(16), 2)),
+		ExpirationDate:      cert.NotAfter.Format(time.UnixDate),
+		SignatureAlgorithm:  cert.SignatureAlgorithm.String(),
+		SANs:                append([]string{}, cert.DNSNames...),
+		Expired:             currentTime.After(cert.NotAfter),
+	}
+
+	for _, ip := range cert.IPAddresses {
+		cd.SANs = append(cd.SANs, ip.String())
+	}
+
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		cd.KeyType = "RSA"
+		cd.KeySize = key.N.BitLen()
+	case *ecdsa.PublicKey:
+		cd.KeyType = "ECDSA"
+		cd.KeySize = key.Curve.Params().BitSize
+	case ed25519.PublicKey:
+		cd.KeyType = "Ed25519"
+		cd.KeySize = 256
+	default:
+		cd.KeyType = cert.PublicKeyAlgorithm.String()
+	}
+
+	// a self-signed certificate names itself as issuer and carries its own signature
+	if bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil {
+		cd.SelfSigned = true
+	}
+
+	return cd
+}
+
+// verifyCertificate checks the chain and the hostname of a leaf certificate and
+// returns the verified chain, if any
+func verifyCertificate(cd *CertificateDetails, leaf *x509.Certificate, peers []*x509.Certificate, serverName string, roots *x509.CertPool) []*x509.Certificate {
+	intermediates := x509.NewCertPool()
+	for _, cert := range peers {
+		if cert != leaf {
+			intermediates.AddCert(cert)
+		}
+	}
+
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	if err != nil {
+		cd.ChainError = err.Error()
+	} else {
+		cd.ChainVerified = true
+		for _, cert := range chains[0] {
+			cd.Chain = append(cd.Chain, lastName(cert.Subject.Names))
+		}
+	}
+
+	if len(cd.Chain) == 0 {
+		for _, cert := range peers {
+			cd.Chain = append(cd.Chain, lastName(cert.Subject.Names))
+		}
+	}
+
+	if err = leaf.VerifyHostname(serverName); err != nil {
+		cd.HostnameError = err.Error()
+	} else {
+		cd.HostnameVerified = true
+	}
+
+	if len(chains) == 0 {
+		return nil
+	}
+	return chains[0]
+}
+
+// lastName returns the value of the last attribute of a distinguished name
+func lastName(names []pkix.AttributeTypeAndValue) string {
+	if len(names) == 0 {
+		return ""
+	}
+
+	value, _ := names[len(names)-1].Value.(string)
+	return value
+}
+
 // CheckExpirationStatus checks the expiration info for a certificate
 func CheckExpirationStatus(cd *CertificateDetails, expirationDaysThreshold int) {
 	if cd.DaysUntilExpiration < 0 {
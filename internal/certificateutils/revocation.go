@@ -0,0 +1,227 @@
+package certificateutils
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// revocation states reported in CertificateDetails.RevocationStatus; an empty
+// status means no revocation information was available
+const (
+	RevocationGood    = "good"
+	RevocationRevoked = "revoked"
+	RevocationUnknown = "unknown"
+)
+
+// maxRevocationResponseBytes is the most read from an OCSP responder or a CRL distribution point
+const maxRevocationResponseBytes = 10 << 20
+
+// checkRevocation fills the revocation status of a leaf certificate. A stapled OCSP
+// response is used first, then the OCSP responder and finally the CRLs, each only
+// when the previous source gave no definite answer. A response or CRL is only
+// trusted when its signature can be checked against the issuer
+func checkRevocation(ctx context.Context, cd *CertificateDetails, leaf, issuer *x509.Certificate, stapled []byte, opts VerifyOptions, timeout time.Duration) {
+	var errs []string
+	attempted := false
+
+	if len(stapled) > 0 {
+		attempted = true
+		cd.OCSPStapled = true
+		if issuer == nil {
+			errs = append(errs, "stapled ocsp: issuer certificate not available to verify the response")
+		} else if resp, err := ocsp.ParseResponseForCert(stapled, leaf, issuer); err != nil {
+			errs = append(errs, fmt.Sprintf("stapled ocsp: %s", err))
+		} else if err = ocspCurrent(resp); err != nil {
+			errs = append(errs, fmt.Sprintf("stapled ocsp: %s", err))
+		} else if setOCSPStatus(cd, resp, "stapled ocsp") {
+			return
+		}
+	}
+
+	client := &http.Client{Timeout: timeout}
+
+	if opts.CheckOCSP {
+		servers := leaf.OCSPServer
+		if opts.OCSPServer != "" {
+			servers = []string{opts.OCSPServer}
+		}
+
+		for _, server := range servers {
+			attempted = true
+			resp, err := queryOCSP(ctx, client, server, leaf, issuer)
+			if err == nil {
+				err = ocspCurrent(resp)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("ocsp %s: %s", server, err))
+				continue
+			}
+			if setOCSPStatus(cd, resp, "ocsp") {
+				return
+			}
+		}
+	}
+
+	if opts.CheckCRL {
+		points := leaf.CRLDistributionPoints
+		if opts.CRLURL != "" {
+			points = []string{opts.CRLURL}
+		}
+
+		for _, point := range points {
+			attempted = true
+			revokedAt, revoked, err := fetchCRLStatus(ctx, client, point, leaf, issuer)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("crl %s: %s", point, err))
+				continue
+			}
+
+			cd.RevocationSource = "crl"
+			if revoked {
+				cd.RevocationStatus = RevocationRevoked
+				cd.RevokedAt = revokedAt
+			} else {
+				cd.RevocationStatus = RevocationGood
+			}
+			return
+		}
+	}
+
+	if attempted {
+		cd.RevocationStatus = RevocationUnknown
+		cd.RevocationError = strings.Join(errs, "; ")
+	}
+}
+
+// setOCSPStatus records an OCSP response and reports whether it was a definite answer
+func setOCSPStatus(cd *CertificateDetails, resp *ocsp.Response, source string) bool {
+	switch resp.Status {
+	case ocsp.Good:
+		cd.RevocationStatus = RevocationGood
+	case ocsp.Revoked:
+		cd.RevocationStatus = RevocationRevoked
+		cd.RevokedAt = resp.RevokedAt
+	default:
+		return false
+	}
+
+	cd.RevocationSource = source
+	return true
+}
+
+// ocspCurrent returns an error when the response is past its next update, so a
+// stale answer is not taken as the current status
+func ocspCurrent(resp *ocsp.Response) error {
+	if !resp.NextUpdate.IsZero() && time.Now().After(resp.NextUpdate) {
+		return fmt.Errorf("response expired on %s", resp.NextUpdate.Format(time.UnixDate))
+	}
+	return nil
+}
+
+// queryOCSP asks an OCSP responder for the status of a certificate
+func queryOCSP(ctx context.Context, client *http.Client, server string, leaf, issuer *x509.Certificate) (*ocsp.Response, error) {
+	if issuer == nil {
+		return nil, errors.New("issuer certificate not available")
+	}
+
+	request, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server, bytes.NewReader(request))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/ocsp-request")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("responder returned %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRevocationResponseBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	return ocsp.ParseResponseForCert(body, leaf, issuer)
+}
+
+// fetchCRLStatus downloads a CRL and reports whether it lists the certificate
+func fetchCRLStatus(ctx context.Context, client *http.Client, url string, leaf, issuer *x509.Certificate) (time.Time, bool, error) {
+	if issuer == nil {
+		return time.Time{}, false, errors.New("issuer certificate not available to verify the crl")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return time.Time{}, false, fmt.Errorf("distribution point returned %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRevocationResponseBytes))
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	crl, err := x509.ParseRevocationList(body)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	if err = crl.CheckSignatureFrom(issuer); err != nil {
+		return time.Time{}, false, err
+	}
+
+	if !crl.NextUpdate.IsZero() && time.Now().After(crl.NextUpdate) {
+		return time.Time{}, false, fmt.Errorf("crl expired on %s", crl.NextUpdate.Format(time.UnixDate))
+	}
+
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(leaf.SerialNumber) == 0 {
+			return entry.RevocationTime, true, nil
+		}
+	}
+
+	return time.Time{}, false, nil
+}
+
+// issuerOf returns the certificate that signed leaf, looked up in the verified
+// chain first and then among the certificates sent by the peer
+func issuerOf(leaf *x509.Certificate, chain, peers []*x509.Certificate) *x509.Certificate {
+	if len(chain) > 1 {
+		return chain[1]
+	}
+
+	for _, cert := range peers {
+		if cert != leaf && bytes.Equal(leaf.RawIssuer, cert.RawSubject) && leaf.CheckSignatureFrom(cert) == nil {
+			return cert
+		}
+	}
+
+	return nil
+}
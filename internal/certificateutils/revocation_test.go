@@ -0,0 +1,319 @@
+package certificateutils
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// testPKI is a CA and a leaf certificate it signed
+type testPKI struct {
+	ca    *x509.Certificate
+	caKey crypto.Signer
+	leaf  *x509.Certificate
+}
+
+func newTestPKI(t *testing.T) testPKI {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "example.test"},
+		DNSNames:     []string{"example.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, ca, leafKey.Public(), caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return testPKI{ca: ca, caKey: caKey, leaf: leaf}
+}
+
+// ocspResponse returns a response for the leaf signed by key
+func (p testPKI) ocspResponse(t *testing.T, status int, key crypto.Signer) []byte {
+	return p.ocspResponseUntil(t, status, key, time.Now().Add(time.Hour))
+}
+
+// ocspResponseUntil returns a response for the leaf signed by key, valid until nextUpdate
+func (p testPKI) ocspResponseUntil(t *testing.T, status int, key crypto.Signer, nextUpdate time.Time) []byte {
+	t.Helper()
+
+	template := ocsp.Response{
+		Status:       status,
+		SerialNumber: p.leaf.SerialNumber,
+		ThisUpdate:   nextUpdate.Add(-2 * time.Hour),
+		NextUpdate:   nextUpdate,
+	}
+	if status == ocsp.Revoked {
+		template.RevokedAt = time.Now().Add(-time.Hour).Truncate(time.Second)
+		template.RevocationReason = ocsp.KeyCompromise
+	}
+
+	resp, err := ocsp.CreateResponse(p.ca, p.ca, template, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// ocspResponder is a local stand-in for the responder of the CA, answering with status
+func (p testPKI) ocspResponder(t *testing.T, status int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		req, err := ocsp.ParseRequest(body)
+		if err != nil || req.SerialNumber.Cmp(p.leaf.SerialNumber) != 0 {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/ocsp-response")
+		_, _ = w.Write(p.ocspResponse(t, status, p.caKey))
+	}))
+}
+
+func TestCheckRevocationOCSPResponder(t *testing.T) {
+	p := newTestPKI(t)
+
+	tests := []struct {
+		name   string
+		status int
+		want   string
+	}{
+		{name: "good", status: ocsp.Good, want: RevocationGood},
+		{name: "revoked", status: ocsp.Revoked, want: RevocationRevoked},
+		{name: "unknown", status: ocsp.Unknown, want: RevocationUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responder := p.ocspResponder(t, tt.status)
+			defer responder.Close()
+
+			var cd CertificateDetails
+			checkRevocation(context.Background(), &cd, p.leaf, p.ca, nil, VerifyOptions{CheckOCSP: true, OCSPServer: responder.URL}, 5*time.Second)
+
+			if cd.RevocationStatus != tt.want {
+				t.Fatalf("got status %q, expected %q (error %q)", cd.RevocationStatus, tt.want, cd.RevocationError)
+			}
+			if tt.want != RevocationUnknown && cd.RevocationSource != "ocsp" {
+				t.Errorf("got source %q, expected ocsp", cd.RevocationSource)
+			}
+			if tt.want == RevocationRevoked && cd.RevokedAt.IsZero() {
+				t.Errorf("revocation time not recorded")
+			}
+		})
+	}
+}
+
+func TestCheckRevocationStapled(t *testing.T) {
+	p := newTestPKI(t)
+
+	forger, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		stapled []byte
+		issuer  *x509.Certificate
+		want    string
+		wantErr string
+	}{
+		{name: "good", stapled: p.ocspResponse(t, ocsp.Good, p.caKey), issuer: p.ca, want: RevocationGood},
+		{name: "revoked", stapled: p.ocspResponse(t, ocsp.Revoked, p.caKey), issuer: p.ca, want: RevocationRevoked},
+		{name: "expired", stapled: p.ocspResponseUntil(t, ocsp.Good, p.caKey, time.Now().Add(-time.Minute)), issuer: p.ca, want: RevocationUnknown, wantErr: "response expired"},
+		{name: "forged", stapled: p.ocspResponse(t, ocsp.Good, forger), issuer: p.ca, want: RevocationUnknown, wantErr: "stapled ocsp"},
+		{name: "no issuer", stapled: p.ocspResponse(t, ocsp.Good, p.caKey), issuer: nil, want: RevocationUnknown, wantErr: "issuer certificate not available"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cd CertificateDetails
+			checkRevocation(context.Background(), &cd, p.leaf, tt.issuer, tt.stapled, VerifyOptions{}, 5*time.Second)
+
+			if !cd.OCSPStapled {
+				t.Errorf("stapled response not recorded")
+			}
+			if cd.RevocationStatus != tt.want {
+				t.Fatalf("got status %q, expected %q (error %q)", cd.RevocationStatus, tt.want, cd.RevocationError)
+			}
+			if tt.want != RevocationUnknown && cd.RevocationSource != "stapled ocsp" {
+				t.Errorf("got source %q, expected stapled ocsp", cd.RevocationSource)
+			}
+			if !strings.Contains(cd.RevocationError, tt.wantErr) {
+				t.Errorf("got error %q, expected it to contain %q", cd.RevocationError, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckRevocationStapledFallsBackToResponder(t *testing.T) {
+	p := newTestPKI(t)
+	responder := p.ocspResponder(t, ocsp.Revoked)
+	defer responder.Close()
+
+	// an unknown staple is no definite answer, so the responder is asked
+	var cd CertificateDetails
+	checkRevocation(context.Background(), &cd, p.leaf, p.ca, p.ocspResponse(t, ocsp.Unknown, p.caKey), VerifyOptions{CheckOCSP: true, OCSPServer: responder.URL}, 5*time.Second)
+
+	if cd.RevocationStatus != RevocationRevoked || cd.RevocationSource != "ocsp" {
+		t.Fatalf("got %q from %q, expected revoked from ocsp (error %q)", cd.RevocationStatus, cd.RevocationSource, cd.RevocationError)
+	}
+}
+
+func TestCheckRevocationExpiredResponder(t *testing.T) {
+	p := newTestPKI(t)
+	responder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/ocsp-response")
+		_, _ = w.Write(p.ocspResponseUntil(t, ocsp.Good, p.caKey, time.Now().Add(-time.Minute)))
+	}))
+	defer responder.Close()
+
+	var cd CertificateDetails
+	checkRevocation(context.Background(), &cd, p.leaf, p.ca, nil, VerifyOptions{CheckOCSP: true, OCSPServer: responder.URL}, 5*time.Second)
+
+	if cd.RevocationStatus != RevocationUnknown || !strings.Contains(cd.RevocationError, "response expired") {
+		t.Fatalf("got %q (error %q), expected unknown for a response past its next update", cd.RevocationStatus, cd.RevocationError)
+	}
+}
+
+// crlServer is a local stand-in crl distribution point of the CA, listing the
+// leaf when revoked and valid until nextUpdate
+func (p testPKI) crlServer(t *testing.T, revoked bool, nextUpdate time.Time) *httptest.Server {
+	t.Helper()
+
+	template := &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: nextUpdate.Add(-2 * time.Hour),
+		NextUpdate: nextUpdate,
+	}
+	if revoked {
+		template.RevokedCertificateEntries = []x509.RevocationListEntry{
+			{SerialNumber: p.leaf.SerialNumber, RevocationTime: time.Now().Add(-time.Hour).Truncate(time.Second)},
+		}
+	}
+
+	crl, err := x509.CreateRevocationList(rand.Reader, template, p.ca, p.caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ca.crl" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pkix-crl")
+		_, _ = w.Write(crl)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestCheckRevocationCRL(t *testing.T) {
+	p := newTestPKI(t)
+	later := time.Now().Add(time.Hour)
+
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr string
+	}{
+		{name: "good", url: p.crlServer(t, false, later).URL + "/ca.crl", want: RevocationGood},
+		{name: "revoked", url: p.crlServer(t, true, later).URL + "/ca.crl", want: RevocationRevoked},
+		{name: "stale", url: p.crlServer(t, true, time.Now().Add(-time.Minute)).URL + "/ca.crl", want: RevocationUnknown, wantErr: "crl expired"},
+		{name: "not found", url: p.crlServer(t, false, later).URL + "/missing.crl", want: RevocationUnknown, wantErr: "404"},
+		{name: "unreachable", url: unreachable.URL + "/ca.crl", want: RevocationUnknown, wantErr: "connection refused"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cd CertificateDetails
+			checkRevocation(context.Background(), &cd, p.leaf, p.ca, nil, VerifyOptions{CheckCRL: true, CRLURL: tt.url}, 5*time.Second)
+
+			if cd.RevocationStatus != tt.want {
+				t.Fatalf("got status %q, expected %q (error %q)", cd.RevocationStatus, tt.want, cd.RevocationError)
+			}
+			if tt.want != RevocationUnknown && cd.RevocationSource != "crl" {
+				t.Errorf("got source %q, expected crl", cd.RevocationSource)
+			}
+			if tt.want == RevocationRevoked && cd.RevokedAt.IsZero() {
+				t.Errorf("revocation time not recorded")
+			}
+			if !strings.Contains(cd.RevocationError, tt.wantErr) {
+				t.Errorf("got error %q, expected it to contain %q", cd.RevocationError, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckRevocationContextCancelled(t *testing.T) {
+	p := newTestPKI(t)
+	responder := p.ocspResponder(t, ocsp.Good)
+	defer responder.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var cd CertificateDetails
+	checkRevocation(ctx, &cd, p.leaf, p.ca, nil, VerifyOptions{CheckOCSP: true, OCSPServer: responder.URL}, 5*time.Second)
+
+	if cd.RevocationStatus != RevocationUnknown || !strings.Contains(cd.RevocationError, "context canceled") {
+		t.Fatalf("got %q (error %q), expected unknown after the context was cancelled", cd.RevocationStatus, cd.RevocationError)
+	}
+}
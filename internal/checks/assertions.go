@@ -0,0 +1,289 @@
+package checks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// statusRange is an inclusive range of http status codes, written in config
+// as a number (200), a range ("200-299") or a class ("2xx")
+type statusRange struct {
+	min int
+	max int
+}
+
+// UnmarshalJSON reads a status range from a number or a string
+func (s *statusRange) UnmarshalJSON(b []byte) error {
+	var n int
+	if err := json.Unmarshal(b, &n); err == nil {
+		s.min, s.max = n, n
+		return nil
+	}
+
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return fmt.Errorf("status code must be a number or a string: %s", b)
+	}
+	str = strings.ToLower(strings.TrimSpace(str))
+
+	var err error
+	switch {
+	case len(str) == 3 && strings.HasSuffix(str, "xx"):
+		var class int
+		class, err = strconv.Atoi(str[:1])
+		s.min, s.max = class*100, class*100+99
+	case strings.Contains(str, "-"):
+		parts := strings.SplitN(str, "-", 2)
+		s.min, err = strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err == nil {
+			s.max, err = strconv.Atoi(strings.TrimSpace(parts[1]))
+		}
+	default:
+		s.min, err = strconv.Atoi(str)
+		s.max = s.min
+	}
+	if err != nil {
+		return fmt.Errorf("invalid status code %q", str)
+	}
+
+	return nil
+}
+
+func (s statusRange) contains(code int) bool {
+	return code >= s.min && code <= s.max
+}
+
+func (s statusRange) String() string {
+	if s.min == s.max {
+		return strconv.Itoa(s.min)
+	}
+	return fmt.Sprintf("%d-%d", s.min, s.max)
+}
+
+// jsonAssertion checks a value in a json response body, selected by a dotted
+// path such as "data.items.0.name"
+type jsonAssertion struct {
+	Path     string `json:"path"`
+	Equals   any    `json:"equals"`
+	Contains string `json:"contains"`
+	Exists   *bool  `json:"exists"`
+}
+
+// httpAssertions holds the assertions run against an http response
+type httpAssertions struct {
+	ExpectedStatus  []statusRange     `json:"expected_status"`
+	BodyContains    []string          `json:"body_contains"`
+	BodyNotContains []string          `json:"body_not_contains"`
+	BodyRegex       string            `json:"body_regex"`
+	BodyNotRegex    string            `json:"body_not_regex"`
+	JSON            []jsonAssertion   `json:"json"`
+	ResponseHeaders map[string]string `json:"response_headers"`
+}
+
+// assertionParams describes the assertion parameters shared by the http check types
+func assertionParams() []Param {
+	return []Param{
+		{Name: "expected_status", Type: "[]string", Default: `["200"]`, Description: `status codes treated as healthy, e.g. [200, "300-399", "2xx"]`},
+		{Name: "body_contains", Type: "[]string", Description: "strings the body must contain"},
+		{Name: "body_not_contains", Type: "[]string", Description: "strings the body must not contain"},
+		{Name: "body_regex", Type: "string", Description: "regular expression the body must match"},
+		{Name: "body_not_regex", Type: "string", Description: "regular expression the body must not match"},
+		{Name: "json", Type: "[]object", Description: `json body assertions, e.g. [{"path": "status", "equals": "ok"}, {"path": "checks", "contains": "db"}]`},
+		{Name: "response_headers", Type: "map", Description: "headers the response must have; a non empty value must be contained in the header"},
+		{Name: "max_body_bytes", Type: "int", Default: "1048576", Description: "most bytes of the body read for assertions"},
+	}
+}
+
+// assertionError is a failed assertion. The name is short enough for the
+// check message; the description may quote the body and goes in the details
+type assertionError struct {
+	name        string
+	description string
+}
+
+func (e *assertionError) Error() string { return e.description }
+
+// assertionFailed returns an assertionError for the named assertion
+func assertionFailed(name, format string, args ...any) error {
+	return &assertionError{name: name, description: fmt.Sprintf(format, args...)}
+}
+
+// assertionName returns the name of a failed assertion, or the error text of
+// anything else
+func assertionName(err error) string {
+	var ae *assertionError
+	if errors.As(err, &ae) {
+		return ae.name
+	}
+	return err.Error()
+}
+
+// check runs every assertion against the response and returns an
+// assertionError describing the first one that failed
+func (a httpAssertions) check(resp *http.Response, body []byte) error {
+	if len(a.ExpectedStatus) > 0 {
+		ok := false
+		for _, sr := range a.ExpectedStatus {
+			if sr.contains(resp.StatusCode) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return assertionFailed("expected_status", "status %d not in expected %v", resp.StatusCode, a.ExpectedStatus)
+		}
+	}
+
+	for name, want := range a.ResponseHeaders {
+		values := resp.Header.Values(name)
+		if len(values) == 0 {
+			return assertionFailed("response_headers", "header %s missing", name)
+		}
+		if want != "" && !strings.Contains(strings.Join(values, ", "), want) {
+			return assertionFailed("response_headers", "header %s is %q, expected it to contain %q", name, strings.Join(values, ", "), want)
+		}
+	}
+
+	for _, s := range a.BodyContains {
+		if !strings.Contains(string(body), s) {
+			return assertionFailed("body_contains", "body does not contain %q", s)
+		}
+	}
+
+	for _, s := range a.BodyNotContains {
+		if strings.Contains(string(body), s) {
+			return assertionFailed("body_not_contains", "body contains %q", s)
+		}
+	}
+
+	if a.BodyRegex != "" {
+		re, err := regexp.Compile(a.BodyRegex)
+		if err != nil {
+			return assertionFailed("body_regex", "invalid body_regex: %s", err)
+		}
+		if !re.Match(body) {
+			return assertionFailed("body_regex", "body does not match %q", a.BodyRegex)
+		}
+	}
+
+	if a.BodyNotRegex != "" {
+		re, err := regexp.Compile(a.BodyNotRegex)
+		if err != nil {
+			return assertionFailed("body_not_regex", "invalid body_not_regex: %s", err)
+		}
+		if re.Match(body) {
+			return assertionFailed("body_not_regex", "body matches %q", a.BodyNotRegex)
+		}
+	}
+
+	if len(a.JSON) > 0 {
+		var doc any
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return assertionFailed("json", "body is not valid json: %s", err)
+		}
+
+		for _, ja := range a.JSON {
+			if err := ja.check(doc); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// check runs a single json assertion against a decoded document
+func (ja jsonAssertion) check(doc any) error {
+	v, found := lookupJSONPath(doc, ja.Path)
+
+	if ja.Exists != nil {
+		if *ja.Exists && !found {
+			return assertionFailed("json "+ja.Path, "json %s missing", ja.Path)
+		}
+		if !*ja.Exists && found {
+			return assertionFailed("json "+ja.Path, "json %s present", ja.Path)
+		}
+	}
+
+	if ja.Equals != nil {
+		if !found {
+			return assertionFailed("json "+ja.Path, "json %s missing", ja.Path)
+		}
+		if fmt.Sprint(v) != fmt.Sprint(ja.Equals) {
+			return assertionFailed("json "+ja.Path, "json %s is %v, expected %v", ja.Path, v, ja.Equals)
+		}
+	}
+
+	if ja.Contains != "" {
+		if !found {
+			return assertionFailed("json "+ja.Path, "json %s missing", ja.Path)
+		}
+		if !jsonContains(v, ja.Contains) {
+			return assertionFailed("json "+ja.Path, "json %s does not contain %q", ja.Path, ja.Contains)
+		}
+	}
+
+	return nil
+}
+
+// lookupJSONPath walks a decoded json document along a dotted path; numeric
+// segments index into arrays, and "items[0]" is accepted for "items.0"
+func lookupJSONPath(doc any, path string) (any, bool) {
+	path = strings.NewReplacer("[", ".", "]", "").Replace(strings.TrimPrefix(path, "$."))
+	if path == "" || path == "$" {
+		return doc, true
+	}
+
+	current := doc
+	for _, segment := range strings.Split(path, ".") {
+		if segment == "" {
+			continue
+		}
+
+		switch node := current.(type) {
+		case map[string]any:
+			v, ok := node[segment]
+			if !ok {
+				return nil, false
+			}
+			current = v
+		case []any:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			current = node[i]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// jsonContains reports whether a string contains s, an array has an element
+// equal to s, or an object has the key s
+func jsonContains(v any, s string) bool {
+	switch node := v.(type) {
+	case string:
+		return strings.Contains(node, s)
+	case []any:
+		for _, item := range node {
+			if fmt.Sprint(item) == s {
+				return true
+			}
+		}
+	case map[string]any:
+		_, ok := node[s]
+		return ok
+	default:
+		return strings.Contains(fmt.Sprint(node), s)
+	}
+
+	return false
+}
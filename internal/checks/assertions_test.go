@@ -0,0 +1,149 @@
+package checks
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestStatusRangeUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in       string
+		min, max int
+		wantErr  bool
+	}{
+		{in: `200`, min: 200, max: 200},
+		{in: `"204"`, min: 204, max: 204},
+		{in: `"200-299"`, min: 200, max: 299},
+		{in: `" 300 - 399 "`, min: 300, max: 399},
+		{in: `"2xx"`, min: 200, max: 299},
+		{in: `"5XX"`, min: 500, max: 599},
+		{in: `"abc"`, wantErr: true},
+		{in: `"200-"`, wantErr: true},
+		{in: `"zxx"`, wantErr: true},
+		{in: `true`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		var s statusRange
+		err := json.Unmarshal([]byte(tt.in), &s)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %+v", tt.in, s)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", tt.in, err)
+			continue
+		}
+		if s.min != tt.min || s.max != tt.max {
+			t.Errorf("%s: got %d-%d, expected %d-%d", tt.in, s.min, s.max, tt.min, tt.max)
+		}
+	}
+}
+
+func TestLookupJSONPath(t *testing.T) {
+	var doc any
+	if err := json.Unmarshal([]byte(`{"status": "ok", "data": {"items": [{"name": "a"}, {"name": "b"}]}, "count": 2}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path  string
+		want  any
+		found bool
+	}{
+		{path: "status", want: "ok", found: true},
+		{path: "$.status", want: "ok", found: true},
+		{path: "count", want: float64(2), found: true},
+		{path: "data.items.1.name", want: "b", found: true},
+		{path: "data.items[0].name", want: "a", found: true},
+		{path: "data.items.2.name", found: false},
+		{path: "data.items.x", found: false},
+		{path: "status.deeper", found: false},
+		{path: "missing", found: false},
+	}
+
+	for _, tt := range tests {
+		got, found := lookupJSONPath(doc, tt.path)
+		if found != tt.found {
+			t.Errorf("%s: found %v, expected %v", tt.path, found, tt.found)
+			continue
+		}
+		if found && got != tt.want {
+			t.Errorf("%s: got %v, expected %v", tt.path, got, tt.want)
+		}
+	}
+
+	if got, found := lookupJSONPath(doc, "$"); !found || got == nil {
+		t.Errorf("$: expected the whole document")
+	}
+}
+
+func TestHTTPAssertionsCheck(t *testing.T) {
+	body := []byte(`{"status": "ok", "version": 3, "checks": ["db", "cache"], "data": {"items": [{"name": "first"}]}}`)
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json; charset=utf-8"}, "X-Request-Id": []string{"abc"}},
+	}
+
+	tests := []struct {
+		name       string
+		assertions string
+		wantName   string
+	}{
+		{name: "no assertions", assertions: `{}`},
+		{name: "status in range", assertions: `{"expected_status": ["2xx"]}`},
+		{name: "status not expected", assertions: `{"expected_status": [201, "300-399"]}`, wantName: "expected_status"},
+		{name: "header present", assertions: `{"response_headers": {"X-Request-Id": ""}}`},
+		{name: "header contains", assertions: `{"response_headers": {"content-type": "application/json"}}`},
+		{name: "header missing", assertions: `{"response_headers": {"X-Cache": ""}}`, wantName: "response_headers"},
+		{name: "header value differs", assertions: `{"response_headers": {"Content-Type": "text/html"}}`, wantName: "response_headers"},
+		{name: "body contains", assertions: `{"body_contains": ["\"status\": \"ok\"", "cache"]}`},
+		{name: "body does not contain", assertions: `{"body_contains": ["ok", "degraded"]}`, wantName: "body_contains"},
+		{name: "body not contains", assertions: `{"body_not_contains": ["error"]}`},
+		{name: "body contains forbidden", assertions: `{"body_not_contains": ["cache"]}`, wantName: "body_not_contains"},
+		{name: "body regex", assertions: `{"body_regex": "\"version\": \\d+"}`},
+		{name: "body regex fails", assertions: `{"body_regex": "^<html"}`, wantName: "body_regex"},
+		{name: "invalid body regex", assertions: `{"body_regex": "("}`, wantName: "body_regex"},
+		{name: "body not regex", assertions: `{"body_not_regex": "(?i)exception"}`},
+		{name: "body not regex fails", assertions: `{"body_not_regex": "items"}`, wantName: "body_not_regex"},
+		{name: "json equals", assertions: `{"json": [{"path": "status", "equals": "ok"}, {"path": "version", "equals": 3}]}`},
+		{name: "json equals differs", assertions: `{"json": [{"path": "status", "equals": "down"}]}`, wantName: "json status"},
+		{name: "json nested path", assertions: `{"json": [{"path": "data.items[0].name", "equals": "first"}]}`},
+		{name: "json contains", assertions: `{"json": [{"path": "checks", "contains": "db"}]}`},
+		{name: "json contains fails", assertions: `{"json": [{"path": "checks", "contains": "queue"}]}`, wantName: "json checks"},
+		{name: "json exists", assertions: `{"json": [{"path": "data.items", "exists": true}, {"path": "error", "exists": false}]}`},
+		{name: "json missing", assertions: `{"json": [{"path": "uptime", "exists": true}]}`, wantName: "json uptime"},
+		{name: "json present", assertions: `{"json": [{"path": "status", "exists": false}]}`, wantName: "json status"},
+		{name: "first failure reported", assertions: `{"expected_status": [204], "body_contains": ["degraded"]}`, wantName: "expected_status"},
+		{
+			name:       "all passing together",
+			assertions: `{"expected_status": [200], "response_headers": {"Content-Type": "json"}, "body_contains": ["db"], "body_regex": "cache", "json": [{"path": "status", "equals": "ok"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		var a httpAssertions
+		if err := json.Unmarshal([]byte(tt.assertions), &a); err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+
+		err := a.check(resp, body)
+		switch {
+		case tt.wantName == "" && err != nil:
+			t.Errorf("%s: unexpected failure %q", tt.name, err)
+		case tt.wantName != "" && err == nil:
+			t.Errorf("%s: expected %s to fail", tt.name, tt.wantName)
+		case err != nil && assertionName(err) != tt.wantName:
+			t.Errorf("%s: got %s failing (%s), expected %s", tt.name, assertionName(err), err, tt.wantName)
+		}
+	}
+
+	var a httpAssertions
+	_ = json.Unmarshal([]byte(`{"json": [{"path": "status", "equals": "ok"}]}`), &a)
+	if err := a.check(resp, []byte("<html></html>")); assertionName(err) != "json" {
+		t.Errorf("got %v for a body that is not json, expected the json assertion to fail", err)
+	}
+}
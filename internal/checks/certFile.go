@@ -0,0 +1,187 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/namhuydao/vigilate/internal/certificateutils"
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func init() {
+	Register(certFileChecker{})
+}
+
+// certFileChecker checks certificates stored on the disk of the monitoring server
+type certFileChecker struct{}
+
+// certFileConfig holds the parameters of a certificate file check
+type certFileConfig struct {
+	expiryThresholds
+	Path       string `json:"path"`
+	KeyFile    string `json:"key_file"`
+	RequireKey bool   `json:"require_key"`
+}
+
+// extensions of the files read when the path is a directory
+var certFileExtensions = map[string]bool{
+	".pem": true,
+	".crt": true,
+	".cer": true,
+}
+
+func (certFileChecker) Kind() string { return "certfile" }
+
+func (certFileChecker) Name() string { return "Certificate File" }
+
+func (certFileChecker) Icon() string { return "fas fa-file-contract" }
+
+func (certFileChecker) Params() []Param {
+	params := []Param{
+		{Name: "path", Type: "string", Description: "PEM file, directory or glob pattern such as /etc/ssl/private/*.pem"},
+		{Name: "key_file", Type: "string", Description: "private key file, defaults to a key in the certificate file or a .key file next to it"},
+		{Name: "require_key", Type: "bool", Default: "false", Description: "report a problem when no private key matches the certificate"},
+	}
+	return append(params, expiryParams()...)
+}
+
+func (certFileChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := certFileConfig{expiryThresholds: defaultExpiryThresholds()}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+
+	files, err := cfg.files()
+	if err != nil {
+		return models.CheckResult{Status: "problem", Message: err.Error(), ErrorClass: ErrorClassConfig}
+	}
+
+	// a directory or glob may also hold keys and CSRs, which are skipped
+	listing := len(files) > 1 || files[0] != cfg.Path
+
+	r := models.CheckResult{Status: "healthy", Details: map[string]string{}}
+	var problems []string
+	var soonest *certificateutils.CertificateDetails
+	count := 0
+
+	for _, file := range files {
+		certs, err := certificateutils.ReadCertificateDetailsFromFile(file, cfg.keyFile(file))
+		if listing && errors.Is(err, certificateutils.ErrNoCertificate) {
+			r.Details[file] = "skipped, no certificate"
+			continue
+		}
+		if err != nil {
+			r.Status = "problem"
+			r.ErrorClass = ErrorClassConfig
+			problems = append(problems, fmt.Sprintf("%s: %s", file, err))
+			continue
+		}
+
+		// the leaf certificate comes first in a PEM bundle
+		if cfg.RequireKey && !certs[0].KeyMatched {
+			r.Status = "problem"
+			r.ErrorClass = ErrorClassAssertion
+			problems = append(problems, fmt.Sprintf("no private key matching %s", file))
+		}
+
+		for i := range certs {
+			cd := certs[i]
+			count++
+			r.Details[fmt.Sprintf("%s: %s", file, cd.SubjectName)] = fmt.Sprintf("expires %s (%d days)", cd.ExpirationDate, cd.DaysUntilExpiration)
+
+			if soonest == nil || cd.DaysUntilExpiration < soonest.DaysUntilExpiration {
+				soonest = &cd
+			}
+		}
+	}
+
+	r.Metrics = map[string]float64{"certificates": float64(count)}
+
+	if count == 0 && len(problems) == 0 {
+		r.Status = "problem"
+		r.Message = fmt.Sprintf("no certificates in %s", cfg.Path)
+		r.ErrorClass = ErrorClassConfig
+		return r
+	}
+
+	if soonest != nil {
+		r.Metrics["days_until_expiration"] = float64(soonest.DaysUntilExpiration)
+		r.Status = worseStatus(r.Status, cfg.expiryThresholds.status(*soonest))
+		if soonest.Expired {
+			r.Message = fmt.Sprintf("%s in %s has expired!", soonest.SubjectName, soonest.Hostname)
+		} else {
+			r.Message = fmt.Sprintf("%s in %s expiring in %d days", soonest.SubjectName, soonest.Hostname, soonest.DaysUntilExpiration)
+		}
+	}
+
+	if len(problems) > 0 {
+		r.Message = strings.TrimPrefix(fmt.Sprintf("%s; %s", r.Message, strings.Join(problems, "; ")), "; ")
+	}
+
+	return r
+}
+
+// files returns the certificate files matched by the path
+func (cfg certFileConfig) files() ([]string, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("no certificate path configured")
+	}
+
+	if info, err := os.Stat(cfg.Path); err == nil && info.IsDir() {
+		entries, err := os.ReadDir(cfg.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		var files []string
+		for _, entry := range entries {
+			if !entry.IsDir() && certFileExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+				files = append(files, filepath.Join(cfg.Path, entry.Name()))
+			}
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no certificate files in %s", cfg.Path)
+		}
+		return files, nil
+	}
+
+	matches, err := filepath.Glob(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && !info.IsDir() {
+			files = append(files, match)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no certificate files match %s", cfg.Path)
+	}
+
+	return files, nil
+}
+
+// keyFile returns the private key file checked against a certificate file; an empty
+// name makes the key be looked up in the certificate file itself
+func (cfg certFileConfig) keyFile(file string) string {
+	if cfg.KeyFile != "" {
+		return cfg.KeyFile
+	}
+
+	if !cfg.RequireKey {
+		return ""
+	}
+
+	sibling := strings.TrimSuffix(file, filepath.Ext(file)) + ".key"
+	if info, err := os.Stat(sibling); err == nil && !info.IsDir() && sibling != file {
+		return sibling
+	}
+
+	return ""
+}
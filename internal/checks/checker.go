@@ -0,0 +1,146 @@
+// Package checks holds the registry of check types that can be run against a host service
+package checks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/namhuydao/vigilate/internal/config"
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+var app *config.AppConfig
+
+// NewChecks sets the application config used by the check types
+func NewChecks(a *config.AppConfig) {
+	app = a
+}
+
+// preferenceInt returns a numeric site preference, or def when it is unset or invalid
+func preferenceInt(name string, def int) int {
+	if app == nil {
+		return def
+	}
+
+	n, err := strconv.Atoi(app.Preference(name))
+	if err != nil {
+		return def
+	}
+
+	return n
+}
+
+// Param describes a parameter a check type accepts
+type Param struct {
+	Name        string
+	Type        string
+	Default     string
+	Description string
+}
+
+// Checker is implemented by every check type
+type Checker interface {
+	// Kind is the unique key stored in the kind column of the services table
+	Kind() string
+	// Name is the human readable name of the check type
+	Name() string
+	// Icon is the font awesome icon class used in the ui
+	Icon() string
+	// Params describes the parameters the check type accepts
+	Params() []Param
+	// Check runs the check for a host service. Duration and CheckedAt are
+	// filled in by the caller when the checker leaves them empty
+	Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult
+}
+
+var (
+	mu       sync.RWMutex
+	checkers = make(map[string]Checker)
+)
+
+// Register makes a check type available by its kind. It panics if the kind
+// is empty or registered twice, the same way database/sql does for drivers
+func Register(c Checker) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if c == nil {
+		panic("checks: Register checker is nil")
+	}
+	if c.Kind() == "" {
+		panic("checks: Register checker with empty kind")
+	}
+	if _, dup := checkers[c.Kind()]; dup {
+		panic("checks: Register called twice for kind " + c.Kind())
+	}
+	checkers[c.Kind()] = c
+}
+
+// Get returns the checker registered for kind
+func Get(kind string) (Checker, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	c, ok := checkers[kind]
+	return c, ok
+}
+
+// All returns every registered checker, sorted by kind
+func All() []Checker {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var all []Checker
+	for _, c := range checkers {
+		all = append(all, c)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Kind() < all[j].Kind() })
+
+	return all
+}
+
+// Run runs a checker for a host service, after validating the parameters every
+// check type shares so a check never starts with a config that cannot work
+func Run(ctx context.Context, c Checker, h models.Host, hs models.HostService) models.CheckResult {
+	if err := validateTimeout(hs.Config); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+
+	return c.Check(ctx, h, hs)
+}
+
+// validateTimeout returns an error when the config sets a timeout that is not a
+// positive number of seconds; the context of such a check expires at once
+func validateTimeout(cfg models.ServiceConfig) error {
+	var shared struct {
+		Timeout *float64 `json:"timeout"`
+	}
+	// a timeout of the wrong type is reported by the checker decoding it
+	if err := cfg.Decode(&shared); err != nil || shared.Timeout == nil {
+		return nil
+	}
+
+	if *shared.Timeout <= 0 {
+		return fmt.Errorf("timeout must be a positive number of seconds, not %s", strconv.FormatFloat(*shared.Timeout, 'g', -1, 64))
+	}
+	return nil
+}
+
+// statusRank orders statuses from best to worst
+var statusRank = map[string]int{
+	"":        0,
+	"healthy": 1,
+	"warning": 2,
+	"problem": 3,
+}
+
+// worseStatus returns whichever of two statuses is worse
+func worseStatus(a, b string) string {
+	if statusRank[b] > statusRank[a] {
+		return b
+	}
+	return a
+}
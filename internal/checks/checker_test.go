@@ -0,0 +1,68 @@
+package checks
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+// stubChecker records whether it ran
+type stubChecker struct {
+	ran *bool
+}
+
+func (stubChecker) Kind() string { return "stub" }
+
+func (stubChecker) Name() string { return "Stub" }
+
+func (stubChecker) Icon() string { return "" }
+
+func (stubChecker) Params() []Param { return nil }
+
+func (c stubChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	*c.ran = true
+	return models.CheckResult{Status: "healthy"}
+}
+
+func TestRunRejectsTimeout(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  models.ServiceConfig
+		wantRun bool
+	}{
+		{name: "no timeout", config: models.ServiceConfig{}, wantRun: true},
+		{name: "positive", config: models.ServiceConfig{"timeout": float64(5)}, wantRun: true},
+		{name: "zero", config: models.ServiceConfig{"timeout": float64(0)}},
+		{name: "negative", config: models.ServiceConfig{"timeout": -3}},
+		{name: "wrong type left to the checker", config: models.ServiceConfig{"timeout": "soon"}, wantRun: true},
+	}
+
+	for _, tt := range tests {
+		ran := false
+		r := Run(context.Background(), stubChecker{ran: &ran}, models.Host{}, models.HostService{Config: tt.config})
+
+		if ran != tt.wantRun {
+			t.Errorf("%s: got ran %v, expected %v", tt.name, ran, tt.wantRun)
+		}
+		if !tt.wantRun && (r.Status != "problem" || r.ErrorClass != ErrorClassConfig) {
+			t.Errorf("%s: got %s (%q): %s, expected a config problem", tt.name, r.Status, r.ErrorClass, r.Message)
+		}
+	}
+}
+
+func TestPasswordParamsMasked(t *testing.T) {
+	for _, c := range All() {
+		for _, p := range c.Params() {
+			if !strings.Contains(p.Name, "password") || strings.HasSuffix(p.Name, "_env") {
+				continue
+			}
+
+			masked := models.ServiceConfig{p.Name: "s3cret"}.Masked()
+			if masked[p.Name] != models.SecretMask {
+				t.Errorf("%s: %s is shown as %v, expected it masked", c.Kind(), p.Name, masked[p.Name])
+			}
+		}
+	}
+}
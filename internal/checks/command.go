@@ -0,0 +1,282 @@
+package checks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func init() {
+	Register(commandChecker{})
+}
+
+// commandChecker runs a nagios compatible plugin from the plugin directory
+type commandChecker struct{}
+
+// commandConfig holds the parameters of a command check
+type commandConfig struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	Timeout int      `json:"timeout"`
+}
+
+// status of each nagios plugin exit code; unknown (3) is a warning with the unknown
+// error class, and any other code, such as a crash, a problem
+var pluginExitStatus = map[int]string{
+	0: "healthy",
+	1: "warning",
+	2: "problem",
+	3: "warning",
+}
+
+// pluginUnknown is the exit code of a plugin that could not determine the status
+const pluginUnknown = 3
+
+// maxPluginText is the most of the first line of plugin output used as the message;
+// the whole line is kept in the details
+const maxPluginText = 200
+
+func (commandChecker) Kind() string { return "command" }
+
+func (commandChecker) Name() string { return "Command" }
+
+func (commandChecker) Icon() string { return "fas fa-terminal" }
+
+func (commandChecker) Params() []Param {
+	return []Param{
+		{Name: "command", Type: "string", Description: "plugin to run, relative to the plugin directory set with PLUGIN_DIR"},
+		{Name: "args", Type: "[]string", Description: "arguments; $HOSTNAME$, $HOSTURL$, $HOSTADDRESS$, $HOSTIP$ and $HOSTIPV6$ are replaced with the host values"},
+		{Name: "timeout", Type: "int", Default: "10", Description: "seconds before the plugin is killed"},
+	}
+}
+
+func (commandChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := commandConfig{Timeout: 10}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+
+	pluginDir := ""
+	if app != nil {
+		pluginDir = app.PluginDir
+	}
+
+	path, err := pluginPath(pluginDir, cfg.Command)
+	if err != nil {
+		return models.CheckResult{Status: "problem", Message: err.Error(), ErrorClass: ErrorClassConfig}
+	}
+
+	macros := strings.NewReplacer(
+		"$HOSTNAME$", h.HostName,
+		"$HOSTURL$", h.URL,
+		"$HOSTADDRESS$", hostAddress(h),
+		"$HOSTIP$", h.IP,
+		"$HOSTIPV6$", h.IPV6,
+	)
+	args := make([]string, len(cfg.Args))
+	for i, arg := range cfg.Args {
+		args[i] = macros.Replace(arg)
+	}
+
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Dir = pluginDir
+	cmd.Env = []string{"PATH=" + os.Getenv("PATH")}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err = cmd.Run()
+	elapsed := time.Since(start)
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return models.CheckResult{
+			Status:     "problem",
+			Message:    fmt.Sprintf("%s timed out after %s", cfg.Command, timeout),
+			Duration:   elapsed,
+			ErrorClass: ErrorClassTimeout,
+		}
+	}
+
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return models.CheckResult{
+			Status:     "problem",
+			Message:    fmt.Sprintf("%s - %s", cfg.Command, err),
+			Duration:   elapsed,
+			ErrorClass: ErrorClassConfig,
+		}
+	}
+
+	text, longText, perfdata := parsePluginOutput(stdout.String())
+	if text == "" {
+		text = strings.TrimSpace(stderr.String())
+	}
+	fullText := text
+	if len([]rune(text)) > maxPluginText {
+		text = string([]rune(text)[:maxPluginText]) + "..."
+	}
+
+	code := cmd.ProcessState.ExitCode()
+	r := models.CheckResult{
+		Message:  text,
+		Duration: elapsed,
+		Details:  map[string]string{"exit_code": strconv.Itoa(code)},
+		Metrics:  parsePerfdata(perfdata),
+	}
+	if text != fullText {
+		r.Details["text"] = fullText
+	}
+	if longText != "" {
+		r.Details["output"] = longText
+	}
+	if perfdata != "" {
+		r.Details["perfdata"] = perfdata
+	}
+
+	status, ok := pluginExitStatus[code]
+	switch {
+	case code == pluginUnknown:
+		r.ErrorClass = ErrorClassUnknown
+		if !strings.HasPrefix(text, "UNKNOWN") {
+			r.Message = "UNKNOWN - " + text
+		}
+	case !ok:
+		status = "problem"
+		r.ErrorClass = ErrorClassUnknown
+		r.Message = fmt.Sprintf("exit code %d - %s", code, text)
+	}
+	r.Status = status
+
+	return r
+}
+
+// pluginPath resolves a plugin inside the plugin directory, refusing anything
+// outside of it or not executable
+func pluginPath(pluginDir, command string) (string, error) {
+	if pluginDir == "" {
+		return "", errors.New("command checks are disabled, no plugin directory is configured")
+	}
+	if command == "" {
+		return "", errors.New("no command configured")
+	}
+	if filepath.IsAbs(command) {
+		return "", fmt.Errorf("command %s must be relative to the plugin directory", command)
+	}
+
+	dir, err := filepath.EvalSymlinks(pluginDir)
+	if err != nil {
+		return "", err
+	}
+
+	// checked before and after resolving symlinks, so neither ../ nor a link escapes
+	path := filepath.Join(dir, command)
+	if !insideDir(dir, path) {
+		return "", fmt.Errorf("command %s is outside the plugin directory", command)
+	}
+
+	path, err = filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	if !insideDir(dir, path) {
+		return "", fmt.Errorf("command %s is outside the plugin directory", command)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+		return "", fmt.Errorf("command %s is not an executable file", command)
+	}
+
+	return path, nil
+}
+
+// insideDir reports whether path is dir or below it
+func insideDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// parsePluginOutput splits the output of a nagios plugin into the first line of
+// text, the long text of the following lines and the performance data, which
+// follows a | on the first line and on any line of the long text
+func parsePluginOutput(output string) (text, longText, perfdata string) {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	var perf, long []string
+
+	text, firstPerf, _ := strings.Cut(lines[0], "|")
+	perf = append(perf, strings.TrimSpace(firstPerf))
+
+	inPerf := false
+	for _, line := range lines[1:] {
+		if inPerf {
+			perf = append(perf, strings.TrimSpace(line))
+			continue
+		}
+
+		before, after, found := strings.Cut(line, "|")
+		long = append(long, before)
+		if found {
+			inPerf = true
+			perf = append(perf, strings.TrimSpace(after))
+		}
+	}
+
+	return strings.TrimSpace(text), strings.TrimSpace(strings.Join(long, "\n")), strings.TrimSpace(strings.Join(perf, " "))
+}
+
+// parsePerfdata reads the values of nagios performance data, written as
+// 'label'=value[unit];[warn];[crit];[min];[max]
+func parsePerfdata(perfdata string) map[string]float64 {
+	metrics := make(map[string]float64)
+
+	for rest := strings.TrimSpace(perfdata); rest != ""; rest = strings.TrimSpace(rest) {
+		var label string
+		if strings.HasPrefix(rest, "'") {
+			end := strings.Index(rest[1:], "'=")
+			if end < 0 {
+				break
+			}
+			label = strings.ReplaceAll(rest[1:end+1], "''", "'")
+			rest = rest[end+2:]
+		} else {
+			eq := strings.Index(rest, "=")
+			if eq < 0 {
+				break
+			}
+			label = rest[:eq]
+			rest = rest[eq:]
+		}
+		rest = strings.TrimPrefix(rest, "=")
+
+		value, remainder, _ := strings.Cut(rest, " ")
+		rest = remainder
+
+		value, _, _ = strings.Cut(value, ";")
+		number := strings.TrimRightFunc(value, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '.'
+		})
+		if f, err := strconv.ParseFloat(number, 64); err == nil {
+			metrics[label] = f
+		}
+	}
+
+	return metrics
+}
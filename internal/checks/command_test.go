@@ -0,0 +1,175 @@
+package checks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/namhuydao/vigilate/internal/config"
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func TestParsePluginOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		text     string
+		longText string
+		perfdata string
+	}{
+		{
+			name:   "text only",
+			output: "OK - all good\n",
+			text:   "OK - all good",
+		},
+		{
+			name:     "perfdata on the first line",
+			output:   "OK - load 0.12 | load1=0.12;1;2 load5=0.30\n",
+			text:     "OK - load 0.12",
+			perfdata: "load1=0.12;1;2 load5=0.30",
+		},
+		{
+			name:     "long text and perfdata on following lines",
+			output:   "WARNING - 2 disks\n/ 91% used\n/var 80% used | /=91%;80;90\n/var=80%;80;90\n",
+			text:     "WARNING - 2 disks",
+			longText: "/ 91% used\n/var 80% used",
+			perfdata: "/=91%;80;90 /var=80%;80;90",
+		},
+		{
+			name:   "empty",
+			output: "",
+			text:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		text, longText, perfdata := parsePluginOutput(tt.output)
+		if text != tt.text || longText != tt.longText || perfdata != tt.perfdata {
+			t.Errorf("%s: got (%q, %q, %q), expected (%q, %q, %q)", tt.name, text, longText, perfdata, tt.text, tt.longText, tt.perfdata)
+		}
+	}
+}
+
+func TestParsePerfdata(t *testing.T) {
+	tests := []struct {
+		perfdata string
+		want     map[string]float64
+	}{
+		{perfdata: "", want: map[string]float64{}},
+		{perfdata: "time=0.012s;1;2;0", want: map[string]float64{"time": 0.012}},
+		{perfdata: "used=91%;80;90 free=512MB", want: map[string]float64{"used": 91, "free": 512}},
+		{perfdata: "size=2048B bytes=10KB count=3c", want: map[string]float64{"size": 2048, "bytes": 10, "count": 3}},
+		{perfdata: "'disk / used'=42% 'it''s'=1", want: map[string]float64{"disk / used": 42, "it's": 1}},
+		{perfdata: "rta=U;100;200 pl=0%", want: map[string]float64{"pl": 0}},
+		{perfdata: "load1=0.5 broken", want: map[string]float64{"load1": 0.5}},
+	}
+
+	for _, tt := range tests {
+		got := parsePerfdata(tt.perfdata)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %v, expected %v", tt.perfdata, got, tt.want)
+		}
+	}
+}
+
+func TestCommandCheckExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	saved := app
+	app = &config.AppConfig{PluginDir: dir}
+	t.Cleanup(func() { app = saved })
+
+	writePlugin := func(name, script string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writePlugin("ok", `echo "OK - fine | time=1s"; exit 0`)
+	writePlugin("warn", `echo "WARNING - slow"; exit 1`)
+	writePlugin("crit", `echo "CRITICAL - down"; exit 2`)
+	writePlugin("unknown", `echo "UNKNOWN - cannot tell"; exit 3`)
+	writePlugin("crash", `echo "segfault" >&2; exit 255`)
+	writePlugin("long", `printf 'OK - %0300d\n' 0; exit 0`)
+
+	tests := []struct {
+		command    string
+		wantStatus string
+		wantClass  string
+		wantPrefix string
+	}{
+		{command: "ok", wantStatus: "healthy", wantPrefix: "OK - fine"},
+		{command: "warn", wantStatus: "warning", wantPrefix: "WARNING - slow"},
+		{command: "crit", wantStatus: "problem", wantPrefix: "CRITICAL - down"},
+		{command: "unknown", wantStatus: "warning", wantClass: ErrorClassUnknown, wantPrefix: "UNKNOWN - cannot tell"},
+		{command: "crash", wantStatus: "problem", wantClass: ErrorClassUnknown, wantPrefix: "exit code 255 - segfault"},
+		{command: "long", wantStatus: "healthy", wantPrefix: "OK - 000"},
+	}
+
+	for _, tt := range tests {
+		r := commandChecker{}.Check(context.Background(), models.Host{}, models.HostService{Config: models.ServiceConfig{"command": tt.command}})
+		if r.Status != tt.wantStatus || r.ErrorClass != tt.wantClass || !strings.HasPrefix(r.Message, tt.wantPrefix) {
+			t.Errorf("%s: got %s (%q) %q, expected %s (%q) %q", tt.command, r.Status, r.ErrorClass, r.Message, tt.wantStatus, tt.wantClass, tt.wantPrefix)
+		}
+		if len([]rune(r.Message)) > maxPluginText+len("...") {
+			t.Errorf("%s: message of %d characters is not capped", tt.command, len(r.Message))
+		}
+	}
+
+	r := commandChecker{}.Check(context.Background(), models.Host{}, models.HostService{Config: models.ServiceConfig{"command": "long"}})
+	if len(r.Details["text"]) != len("OK - ")+300 {
+		t.Errorf("the whole first line is not kept in the details: %q", r.Details["text"])
+	}
+}
+
+func TestCommandCheckRejectsOutsidePluginDir(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "plugins")
+	outside := filepath.Join(root, "outside")
+	marker := filepath.Join(root, "ran")
+	for _, d := range []string{dir, outside} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	saved := app
+	app = &config.AppConfig{PluginDir: dir}
+	t.Cleanup(func() { app = saved })
+
+	evil := filepath.Join(outside, "evil")
+	if err := os.WriteFile(evil, []byte("#!/bin/sh\ntouch "+marker+"\necho OK\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(evil, filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(dir, "linkdir")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		command string
+	}{
+		{name: "parent traversal", command: "../outside/evil"},
+		{name: "traversal through a subdirectory", command: "linkdir/../../outside/evil"},
+		{name: "absolute path outside", command: evil},
+		{name: "symlink to a file outside", command: "link"},
+		{name: "symlink to a directory outside", command: "linkdir/evil"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := commandChecker{}.Check(context.Background(), models.Host{}, models.HostService{Config: models.ServiceConfig{"command": tt.command}})
+
+			if r.Status != "problem" || r.ErrorClass != ErrorClassConfig {
+				t.Errorf("got %s (%q): %s, expected a config problem", r.Status, r.ErrorClass, r.Message)
+			}
+			if _, err := os.Stat(marker); err == nil {
+				t.Fatalf("%s was run", tt.command)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package checks
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+// passwordEnvPrefix is the prefix of the environment variables a check may read a
+// password from, so a check cannot send the secrets of vigilate itself to a host
+const passwordEnvPrefix = "VIGILATE_CHECK_"
+
+// loginConfig holds the credentials a check logs in with. A password is stored in
+// the config of the host service and masked when the config is shown; password_env
+// keeps it out of the database altogether and is preferred
+type loginConfig struct {
+	User        string `json:"user"`
+	Password    string `json:"password"`
+	PasswordEnv string `json:"password_env"`
+}
+
+// loginParams describes the credential parameters
+func loginParams() []Param {
+	return []Param{
+		{Name: "user", Type: "string", Description: "user to log in as"},
+		{Name: "password", Type: "string", Description: "password of the user, stored with the host service and shown masked; prefer password_env"},
+		{Name: "password_env", Type: "string", Description: "environment variable of vigilate holding the password, used instead of password; its name must start with " + passwordEnvPrefix},
+	}
+}
+
+// checkPasswordEnv refuses a password_env outside the variables checks may read
+func (cfg loginConfig) checkPasswordEnv() error {
+	if cfg.PasswordEnv != "" && !strings.HasPrefix(cfg.PasswordEnv, passwordEnvPrefix) {
+		return fmt.Errorf("password_env %s must start with %s", cfg.PasswordEnv, passwordEnvPrefix)
+	}
+	return nil
+}
+
+// password returns the configured password, read from the environment when password_env is set
+func (cfg loginConfig) password() string {
+	if cfg.PasswordEnv != "" {
+		if cfg.checkPasswordEnv() != nil {
+			return ""
+		}
+		return os.Getenv(cfg.PasswordEnv)
+	}
+	return cfg.Password
+}
+
+// databaseConfig holds the parameters shared by the database checks
+type databaseConfig struct {
+	latencyThresholds
+	loginConfig
+	Port     int      `json:"port"`
+	Database string   `json:"database"`
+	Query    string   `json:"query"`
+	Timeout  int      `json:"timeout"`
+	Expected string   `json:"expected"`
+	MinValue *float64 `json:"min_value"`
+	MaxValue *float64 `json:"max_value"`
+}
+
+// databaseParams describes the parameters shared by the database checks
+func databaseParams(port, query string) []Param {
+	params := []Param{
+		{Name: "port", Type: "int", Default: port, Description: "port the server listens on"},
+	}
+	params = append(params, loginParams()...)
+	params = append(params, []Param{
+		{Name: "database", Type: "string", Description: "database to connect to"},
+		{Name: "query", Type: "string", Default: query, Description: "query to run; the first column of the first row is its value"},
+		{Name: "timeout", Type: "int", Default: "5", Description: "seconds to connect and run the query"},
+		{Name: "expected", Type: "string", Description: "value the query must return"},
+		{Name: "min_value", Type: "float", Description: "lowest numeric value the query may return"},
+		{Name: "max_value", Type: "float", Description: "highest numeric value the query may return, e.g. a replication lag in seconds"},
+	}...)
+	return append(params, latencyParams()...)
+}
+
+// address returns the host and port of the server
+func (cfg databaseConfig) address(h models.Host) string {
+	return net.JoinHostPort(hostAddress(h), strconv.Itoa(cfg.Port))
+}
+
+// check runs the assertions against the value returned by the query
+func (cfg databaseConfig) check(value string) error {
+	if cfg.Expected != "" && value != cfg.Expected {
+		return fmt.Errorf("returned %q, expected %q", value, cfg.Expected)
+	}
+
+	if cfg.MinValue == nil && cfg.MaxValue == nil {
+		return nil
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return fmt.Errorf("returned %q, expected a number", value)
+	}
+	if cfg.MinValue != nil && n < *cfg.MinValue {
+		return fmt.Errorf("returned %g, expected at least %g", n, *cfg.MinValue)
+	}
+	if cfg.MaxValue != nil && n > *cfg.MaxValue {
+		return fmt.Errorf("returned %g, expected at most %g", n, *cfg.MaxValue)
+	}
+
+	return nil
+}
+
+// run times query within the timeout and turns its value or error into a check result
+func (cfg databaseConfig) run(ctx context.Context, address string, query func(ctx context.Context) (string, error)) models.CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	value, err := query(ctx)
+	elapsed := time.Since(start)
+	if err != nil {
+		return models.CheckResult{
+			Status:     "problem",
+			Message:    fmt.Sprintf("%s - %s", address, err),
+			Duration:   elapsed,
+			ErrorClass: classifyError(err),
+			Details:    map[string]string{"query": cfg.Query},
+		}
+	}
+
+	r := models.CheckResult{
+		Status:   "healthy",
+		Message:  fmt.Sprintf("%s - %s returned %s", address, cfg.Query, value),
+		Duration: elapsed,
+		Details:  map[string]string{"query": cfg.Query, "value": value},
+		Metrics:  map[string]float64{"query_ms": float64(elapsed) / float64(time.Millisecond)},
+	}
+	if n, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+		r.Metrics["value"] = n
+	}
+
+	if err = cfg.check(value); err != nil {
+		r.Status = "problem"
+		r.Message = fmt.Sprintf("%s - assertion failed: %s %s", address, cfg.Query, err)
+		r.ErrorClass = ErrorClassAssertion
+		return r
+	}
+
+	cfg.apply(&r)
+	return r
+}
+
+// querySQL connects with a database/sql driver and returns the first column of
+// the first row of a query, NULL for a null value and an empty string when
+// there are no rows
+func querySQL(ctx context.Context, driverName, dsn, query string) (string, error) {
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	if !rows.Next() || len(columns) == 0 {
+		return "", rows.Err()
+	}
+
+	values := make([]sql.NullString, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err = rows.Scan(dest...); err != nil {
+		return "", err
+	}
+
+	if !values[0].Valid {
+		return "NULL", nil
+	}
+	return values[0].String, nil
+}
@@ -0,0 +1,261 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func init() {
+	Register(dnsChecker{})
+}
+
+// dnsChecker resolves a record of the host name
+type dnsChecker struct{}
+
+// dnsConfig holds the parameters of a dns check
+type dnsConfig struct {
+	latencyThresholds
+	Name        string   `json:"name"`
+	RecordType  string   `json:"record_type"`
+	Resolver    string   `json:"resolver"`
+	Expected    []string `json:"expected"`
+	MinRecords  int      `json:"min_records"`
+	MatchHostIP *bool    `json:"match_host_ip"`
+	Timeout     int      `json:"timeout"`
+}
+
+// record types a dns check can resolve
+var dnsRecordTypes = map[string]bool{
+	"A":     true,
+	"AAAA":  true,
+	"CNAME": true,
+	"MX":    true,
+	"TXT":   true,
+	"SRV":   true,
+}
+
+func (dnsChecker) Kind() string { return "dns" }
+
+func (dnsChecker) Name() string { return "DNS" }
+
+func (dnsChecker) Icon() string { return "fas fa-sitemap" }
+
+func (dnsChecker) Params() []Param {
+	params := []Param{
+		{Name: "name", Type: "string", Description: "name to resolve, defaults to the host name of the url"},
+		{Name: "record_type", Type: "string", Default: "A", Description: "one of A, AAAA, CNAME, MX, TXT, SRV"},
+		{Name: "resolver", Type: "string", Description: "dns server as host:port, defaults to the system resolver"},
+		{Name: "expected", Type: "[]string", Description: "values that must be among the records; MX and SRV records also match on their target"},
+		{Name: "min_records", Type: "int", Default: "1", Description: "fewest records that must be returned"},
+		{Name: "match_host_ip", Type: "bool", Default: "true", Description: "A and AAAA records must contain the ip address set on the host"},
+		{Name: "timeout", Type: "int", Default: "5", Description: "seconds to wait for the answer"},
+	}
+	return append(params, latencyParams()...)
+}
+
+func (dnsChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := dnsConfig{RecordType: "A", MinRecords: 1, Timeout: 5}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+
+	name := cfg.Name
+	if name == "" {
+		name = hostName(h)
+	}
+	if name == "" {
+		return models.CheckResult{Status: "problem", Message: "no name to resolve", ErrorClass: ErrorClassConfig}
+	}
+	recordType := strings.ToUpper(cfg.RecordType)
+	if !dnsRecordTypes[recordType] {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("unsupported record type %q", cfg.RecordType), ErrorClass: ErrorClassConfig}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	records, err := cfg.lookup(ctx, recordType, name)
+	elapsed := time.Since(start)
+	if err != nil {
+		return models.CheckResult{
+			Status:     "problem",
+			Message:    fmt.Sprintf("%s %s - %s", recordType, name, err),
+			Duration:   elapsed,
+			ErrorClass: classifyError(err),
+		}
+	}
+
+	r := models.CheckResult{
+		Status:   "healthy",
+		Message:  fmt.Sprintf("%s %s - %s", recordType, name, strings.Join(records, ", ")),
+		Duration: elapsed,
+		Details:  map[string]string{"records": strings.Join(records, "\n")},
+		Metrics: map[string]float64{
+			"records":   float64(len(records)),
+			"lookup_ms": float64(elapsed) / float64(time.Millisecond),
+		},
+	}
+	if cfg.Resolver != "" {
+		r.Details["resolver"] = cfg.Resolver
+	}
+
+	if err = cfg.check(h, recordType, records); err != nil {
+		r.Status = "problem"
+		r.Message = fmt.Sprintf("%s %s - assertion failed: %s", recordType, name, err)
+		r.ErrorClass = ErrorClassAssertion
+		return r
+	}
+
+	cfg.apply(&r)
+	return r
+}
+
+// resolver returns the resolver querying the configured dns server and its address
+func (cfg dnsConfig) resolver() (*net.Resolver, string) {
+	if cfg.Resolver == "" {
+		return net.DefaultResolver, ""
+	}
+
+	server := cfg.Resolver
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+
+	return resolver, server
+}
+
+// lookup resolves the records of a type, formatted as strings
+func (cfg dnsConfig) lookup(ctx context.Context, recordType, name string) ([]string, error) {
+	resolver, server := cfg.resolver()
+	records, err := lookupRecords(ctx, resolver, recordType, name)
+
+	// the go resolver names the system nameserver even when dialing another one
+	var dnsErr *net.DNSError
+	if server != "" && errors.As(err, &dnsErr) {
+		dnsErr.Server = server
+	}
+
+	return records, err
+}
+
+// lookupRecords resolves the records of a type with a resolver
+func lookupRecords(ctx context.Context, resolver *net.Resolver, recordType, name string) ([]string, error) {
+	var records []string
+
+	switch recordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			records = append(records, ip.String())
+		}
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, strings.TrimSuffix(cname, "."))
+	case "MX":
+		mxs, err := resolver.LookupMX(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			records = append(records, fmt.Sprintf("%d %s", mx.Pref, strings.TrimSuffix(mx.Host, ".")))
+		}
+	case "TXT":
+		txts, err := resolver.LookupTXT(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, txts...)
+	case "SRV":
+		_, srvs, err := resolver.LookupSRV(ctx, "", "", name)
+		if err != nil {
+			return nil, err
+		}
+		for _, srv := range srvs {
+			records = append(records, fmt.Sprintf("%d %d %d %s", srv.Priority, srv.Weight, srv.Port, strings.TrimSuffix(srv.Target, ".")))
+		}
+	}
+
+	return records, nil
+}
+
+// check runs the assertions against the resolved records
+func (cfg dnsConfig) check(h models.Host, recordType string, records []string) error {
+	if len(records) < cfg.MinRecords {
+		return fmt.Errorf("%d records, expected at least %d", len(records), cfg.MinRecords)
+	}
+
+	for _, want := range cfg.Expected {
+		if !dnsRecordsContain(records, want) {
+			return fmt.Errorf("%q not among the records", want)
+		}
+	}
+
+	if cfg.MatchHostIP == nil || *cfg.MatchHostIP {
+		hostIP := ""
+		switch recordType {
+		case "A":
+			hostIP = h.IP
+		case "AAAA":
+			hostIP = h.IPV6
+		}
+
+		if hostIP != "" && !dnsRecordsContain(records, hostIP) {
+			return fmt.Errorf("host address %s not among the records", hostIP)
+		}
+	}
+
+	return nil
+}
+
+// dnsRecordsContain reports whether a record equals want, ignoring case and a
+// trailing dot; ip addresses are compared parsed and MX and SRV records also
+// match on their target alone
+func dnsRecordsContain(records []string, want string) bool {
+	want = strings.TrimSuffix(strings.TrimSpace(want), ".")
+	wantIP := net.ParseIP(want)
+
+	for _, record := range records {
+		if strings.EqualFold(record, want) {
+			return true
+		}
+
+		if wantIP != nil && wantIP.Equal(net.ParseIP(record)) {
+			return true
+		}
+
+		fields := strings.Fields(record)
+		if len(fields) > 1 {
+			if _, err := strconv.Atoi(fields[0]); err == nil && strings.EqualFold(fields[len(fields)-1], want) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
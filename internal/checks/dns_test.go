@@ -0,0 +1,194 @@
+package checks
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/namhuydao/vigilate/internal/models"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dnsStub is a local stand-in dns server answering from a fixed zone over udp
+type dnsStub struct {
+	conn net.PacketConn
+}
+
+func newDNSStub(t *testing.T) *dnsStub {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &dnsStub{conn: conn}
+	go s.serve()
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return s
+}
+
+func (s *dnsStub) address() string {
+	return s.conn.LocalAddr().String()
+}
+
+func (s *dnsStub) serve() {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+
+		var query dnsmessage.Message
+		if err = query.Unpack(buf[:n]); err != nil || len(query.Questions) == 0 {
+			continue
+		}
+
+		msg := dnsStubAnswer(query)
+		resp, err := msg.Pack()
+		if err != nil {
+			continue
+		}
+		_, _ = s.conn.WriteTo(resp, addr)
+	}
+}
+
+// dnsStubAnswer looks the question up in the zone of example.test
+func dnsStubAnswer(query dnsmessage.Message) dnsmessage.Message {
+	q := query.Questions[0]
+	resp := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: query.ID, Response: true, Authoritative: true},
+		Questions: []dnsmessage.Question{q},
+	}
+
+	if !strings.EqualFold(q.Name.String(), "example.test.") {
+		resp.RCode = dnsmessage.RCodeNameError
+		return resp
+	}
+
+	header := dnsmessage.ResourceHeader{Name: q.Name, Type: q.Type, Class: dnsmessage.ClassINET, TTL: 60}
+	switch q.Type {
+	case dnsmessage.TypeA:
+		resp.Answers = []dnsmessage.Resource{
+			{Header: header, Body: &dnsmessage.AResource{A: [4]byte{192, 0, 2, 10}}},
+			{Header: header, Body: &dnsmessage.AResource{A: [4]byte{192, 0, 2, 11}}},
+		}
+	case dnsmessage.TypeMX:
+		resp.Answers = []dnsmessage.Resource{
+			{Header: header, Body: &dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("mail.example.test.")}},
+		}
+	case dnsmessage.TypeTXT:
+		resp.Answers = []dnsmessage.Resource{
+			{Header: header, Body: &dnsmessage.TXTResource{TXT: []string{"v=spf1 -all"}}},
+		}
+	}
+
+	return resp
+}
+
+func TestDNSCheck(t *testing.T) {
+	stub := newDNSStub(t)
+
+	tests := []struct {
+		name       string
+		host       models.Host
+		config     models.ServiceConfig
+		wantStatus string
+		wantClass  string
+		wantRecord string
+	}{
+		{
+			name:       "a record",
+			host:       models.Host{URL: "https://example.test", IP: "192.0.2.11"},
+			config:     models.ServiceConfig{"expected": []any{"192.0.2.10"}},
+			wantStatus: "healthy",
+			wantRecord: "192.0.2.10",
+		},
+		{
+			name:       "host ip not among the records",
+			host:       models.Host{URL: "https://example.test", IP: "192.0.2.99"},
+			config:     models.ServiceConfig{},
+			wantStatus: "problem",
+			wantClass:  ErrorClassAssertion,
+		},
+		{
+			name:       "mx matches on its target",
+			host:       models.Host{URL: "https://example.test"},
+			config:     models.ServiceConfig{"record_type": "MX", "expected": []any{"mail.example.test."}},
+			wantStatus: "healthy",
+			wantRecord: "10 mail.example.test",
+		},
+		{
+			name:       "mx matches with its preference",
+			host:       models.Host{URL: "https://example.test"},
+			config:     models.ServiceConfig{"record_type": "mx", "expected": []any{"10 MAIL.example.test"}},
+			wantStatus: "healthy",
+		},
+		{
+			name:       "txt not expected",
+			host:       models.Host{URL: "https://example.test"},
+			config:     models.ServiceConfig{"record_type": "TXT", "expected": []any{"v=spf1 ~all"}},
+			wantStatus: "problem",
+			wantClass:  ErrorClassAssertion,
+		},
+		{
+			name:       "too few records",
+			host:       models.Host{URL: "https://example.test"},
+			config:     models.ServiceConfig{"min_records": 3},
+			wantStatus: "problem",
+			wantClass:  ErrorClassAssertion,
+		},
+		{
+			name:       "nxdomain",
+			host:       models.Host{URL: "https://missing.example.test"},
+			config:     models.ServiceConfig{"name": "missing.example.test."},
+			wantStatus: "problem",
+			wantClass:  ErrorClassDNS,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config["resolver"] = stub.address()
+			r := dnsChecker{}.Check(context.Background(), tt.host, models.HostService{Config: tt.config})
+
+			if r.Status != tt.wantStatus || r.ErrorClass != tt.wantClass {
+				t.Fatalf("got %s (%q): %s, expected %s (%q)", r.Status, r.ErrorClass, r.Message, tt.wantStatus, tt.wantClass)
+			}
+			if tt.wantRecord != "" && !strings.Contains(r.Details["records"], tt.wantRecord) {
+				t.Errorf("records %q do not contain %q", r.Details["records"], tt.wantRecord)
+			}
+			if tt.wantStatus == "healthy" && r.Details["resolver"] != stub.address() {
+				t.Errorf("got resolver %q, expected %q", r.Details["resolver"], stub.address())
+			}
+			if tt.wantClass == ErrorClassDNS && !strings.Contains(r.Message, stub.address()) {
+				t.Errorf("message %q does not name the resolver %s", r.Message, stub.address())
+			}
+		})
+	}
+}
+
+func TestDNSResolverOverride(t *testing.T) {
+	tests := []struct {
+		resolver string
+		want     string
+	}{
+		{resolver: "", want: ""},
+		{resolver: "192.0.2.53", want: "192.0.2.53:53"},
+		{resolver: "192.0.2.53:5353", want: "192.0.2.53:5353"},
+		{resolver: "2001:db8::53", want: "[2001:db8::53]:53"},
+		{resolver: "[2001:db8::53]:5353", want: "[2001:db8::53]:5353"},
+	}
+
+	for _, tt := range tests {
+		resolver, server := dnsConfig{Resolver: tt.resolver}.resolver()
+		if server != tt.want {
+			t.Errorf("%q: got server %q, expected %q", tt.resolver, server, tt.want)
+		}
+		if tt.resolver == "" && resolver != net.DefaultResolver {
+			t.Errorf("expected the system resolver without an override")
+		}
+	}
+}
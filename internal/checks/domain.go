@@ -0,0 +1,337 @@
+package checks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+	"golang.org/x/net/publicsuffix"
+)
+
+func init() {
+	Register(domainChecker{})
+}
+
+// default servers asked for the registration of a domain; rdap.org redirects to
+// the rdap server of the registry and whois.iana.org refers to its whois server
+const (
+	defaultRDAPServer  = "https://rdap.org"
+	defaultWHOISServer = "whois.iana.org"
+)
+
+// default expiry thresholds in days of a domain registration
+const (
+	defaultDomainWarningDays = 30
+	defaultDomainProblemDays = 7
+)
+
+// defaultDomainTimeout is the default budget in seconds of the whole lookup, which
+// stays inside the time a check may run
+const defaultDomainTimeout = 25
+
+// maxWHOISResponse is the most read of a whois response
+const maxWHOISResponse = 1 << 20
+
+// whoisExpiryPattern matches the expiration date line of common whois formats
+var whoisExpiryPattern = regexp.MustCompile(`(?im)^\s*(?:registry expiry date|registrar registration expiration date|expiration date|expiry date|expiration time|expires(?: on)?|paid-till|renewal date)\s*:\s*(.+?)\s*$`)
+
+// whoisReferPattern matches the referral of whois.iana.org to the whois server of a tld
+var whoisReferPattern = regexp.MustCompile(`(?im)^\s*(?:refer|whois)\s*:\s*(\S+)\s*$`)
+
+// whoisRegistrarPattern matches the registrar line of common whois formats
+var whoisRegistrarPattern = regexp.MustCompile(`(?im)^\s*registrar\s*:\s*(.+?)\s*$`)
+
+// date layouts found in whois responses
+var whoisDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02",
+	"2006.01.02",
+	"2006/01/02",
+	"02-Jan-2006",
+	"02.01.2006",
+	"January 02 2006",
+}
+
+// domainChecker reads the expiration date of the registration of the domain of the host
+type domainChecker struct{}
+
+// domainConfig holds the parameters of a domain expiry check
+type domainConfig struct {
+	expiryThresholds
+	Domain      string `json:"domain"`
+	RDAPServer  string `json:"rdap_server"`
+	WHOISServer string `json:"whois_server"`
+	Timeout     int    `json:"timeout"`
+}
+
+// domainRegistration is what was learnt about the registration of a domain
+type domainRegistration struct {
+	expires   time.Time
+	registrar string
+	source    string
+}
+
+func (domainChecker) Kind() string { return "domain" }
+
+func (domainChecker) Name() string { return "Domain Expiry" }
+
+func (domainChecker) Icon() string { return "fas fa-globe" }
+
+func (domainChecker) Params() []Param {
+	params := []Param{
+		{Name: "domain", Type: "string", Description: "domain to check, defaults to the registrable domain of the host url"},
+		{Name: "rdap_server", Type: "string", Default: defaultRDAPServer, Description: "base url of the rdap server, queried at /domain/<domain>"},
+		{Name: "whois_server", Type: "string", Description: "whois server as host[:port] asked when rdap fails, defaults to the server " + defaultWHOISServer + " refers to"},
+		{Name: "timeout", Type: "int", Default: strconv.Itoa(defaultDomainTimeout), Description: "seconds for the whole lookup; rdap gets at most half, leaving the rest to the whois queries"},
+	}
+	return append(params, expiryParamsOf("registration", defaultDomainWarningDays, defaultDomainProblemDays)...)
+}
+
+func (domainChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := domainConfig{
+		expiryThresholds: expiryThresholds{WarningDays: defaultDomainWarningDays, ProblemDays: defaultDomainProblemDays},
+		RDAPServer:       defaultRDAPServer,
+		Timeout:          defaultDomainTimeout,
+	}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+
+	domain := cfg.Domain
+	if domain == "" {
+		var err error
+		domain, err = publicsuffix.EffectiveTLDPlusOne(hostName(h))
+		if err != nil {
+			return models.CheckResult{Status: "problem", Message: fmt.Sprintf("no registrable domain: %s", err), ErrorClass: ErrorClassConfig}
+		}
+	}
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	reg, rdapErr := cfg.queryRDAP(ctx, domain)
+	var whoisErr error
+	if rdapErr != nil {
+		reg, whoisErr = cfg.queryWHOIS(ctx, domain)
+	}
+	elapsed := time.Since(start)
+
+	if whoisErr != nil {
+		return models.CheckResult{
+			Status:     "problem",
+			Message:    fmt.Sprintf("%s - no expiration date: rdap: %s; whois: %s", domain, rdapErr, whoisErr),
+			Duration:   elapsed,
+			ErrorClass: classifyError(whoisErr),
+		}
+	}
+
+	now := time.Now()
+	days := int(reg.expires.Sub(now).Hours() / 24)
+	expired := now.After(reg.expires)
+
+	r := models.CheckResult{
+		Status:   cfg.statusIn(days, expired),
+		Duration: elapsed,
+		Details: map[string]string{
+			"domain":          domain,
+			"expiration_date": reg.expires.Format(time.UnixDate),
+			"source":          reg.source,
+		},
+		Metrics: map[string]float64{"days_until_expiration": float64(days)},
+	}
+	if reg.registrar != "" {
+		r.Details["registrar"] = reg.registrar
+	}
+	if rdapErr != nil {
+		r.Details["rdap_error"] = rdapErr.Error()
+	}
+
+	if expired {
+		r.Message = fmt.Sprintf("%s registration has expired!", domain)
+	} else {
+		r.Message = fmt.Sprintf("%s registration expiring in %d days", domain, days)
+	}
+
+	return r
+}
+
+// rdapDomain is the part of an rdap domain response the check reads
+type rdapDomain struct {
+	Events []struct {
+		Action string `json:"eventAction"`
+		Date   string `json:"eventDate"`
+	} `json:"events"`
+	Entities []struct {
+		Roles      []string `json:"roles"`
+		VCardArray []any    `json:"vcardArray"`
+	} `json:"entities"`
+}
+
+// queryRDAP reads the registration of a domain from the rdap server
+func (cfg domainConfig) queryRDAP(ctx context.Context, domain string) (domainRegistration, error) {
+	timeout := cfg.queryBudget(ctx, 2)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	url := strings.TrimSuffix(cfg.RDAPServer, "/") + "/domain/" + domain
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return domainRegistration{}, err
+	}
+	req.Header.Set("Accept", "application/rdap+json, application/json")
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return domainRegistration{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return domainRegistration{}, fmt.Errorf("%s returned %s", url, resp.Status)
+	}
+
+	var doc rdapDomain
+	if err = json.NewDecoder(io.LimitReader(resp.Body, maxWHOISResponse)).Decode(&doc); err != nil {
+		return domainRegistration{}, fmt.Errorf("invalid rdap response: %w", err)
+	}
+
+	reg := domainRegistration{source: resp.Request.URL.String()}
+	for _, event := range doc.Events {
+		if event.Action == "expiration" {
+			reg.expires, err = time.Parse(time.RFC3339, event.Date)
+			if err != nil {
+				return reg, fmt.Errorf("invalid expiration date %q", event.Date)
+			}
+		}
+	}
+	if reg.expires.IsZero() {
+		return reg, errors.New("no expiration event in the rdap response")
+	}
+
+	for _, entity := range doc.Entities {
+		for _, role := range entity.Roles {
+			if role == "registrar" {
+				reg.registrar = vcardName(entity.VCardArray)
+			}
+		}
+	}
+
+	return reg, nil
+}
+
+// vcardName returns the fn property of a jCard, as found in rdap entities
+func vcardName(vcard []any) string {
+	if len(vcard) < 2 {
+		return ""
+	}
+
+	properties, _ := vcard[1].([]any)
+	for _, p := range properties {
+		property, _ := p.([]any)
+		if len(property) == 4 && property[0] == "fn" {
+			name, _ := property[3].(string)
+			return name
+		}
+	}
+	return ""
+}
+
+// queryWHOIS reads the registration of a domain from whois, asking the
+// configured server or else the server whois.iana.org refers to
+func (cfg domainConfig) queryWHOIS(ctx context.Context, domain string) (domainRegistration, error) {
+	server := cfg.WHOISServer
+	if server == "" {
+		response, err := cfg.whois(ctx, defaultWHOISServer, domain, cfg.queryBudget(ctx, 2))
+		if err != nil {
+			return domainRegistration{}, err
+		}
+		match := whoisReferPattern.FindStringSubmatch(response)
+		if match == nil {
+			return domainRegistration{}, fmt.Errorf("%s has no whois server for %s", defaultWHOISServer, domain)
+		}
+		server = match[1]
+	}
+
+	response, err := cfg.whois(ctx, server, domain, cfg.queryBudget(ctx, 1))
+	if err != nil {
+		return domainRegistration{}, err
+	}
+
+	match := whoisExpiryPattern.FindStringSubmatch(response)
+	if match == nil {
+		return domainRegistration{}, fmt.Errorf("no expiration date in the response of %s", server)
+	}
+
+	reg := domainRegistration{source: "whois://" + server}
+	for _, layout := range whoisDateLayouts {
+		if t, err := time.Parse(layout, match[1]); err == nil {
+			reg.expires = t
+			break
+		}
+	}
+	if reg.expires.IsZero() {
+		return reg, fmt.Errorf("unrecognised expiration date %q", match[1])
+	}
+
+	if m := whoisRegistrarPattern.FindStringSubmatch(response); m != nil {
+		reg.registrar = m[1]
+	}
+
+	return reg, nil
+}
+
+// queryBudget returns the time a query may take when it is one of n queries left in
+// the lookup, which share the time before the deadline of ctx equally
+func (cfg domainConfig) queryBudget(ctx context.Context, n int) time.Duration {
+	budget := time.Duration(cfg.Timeout) * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		budget = min(budget, time.Until(deadline)/time.Duration(n))
+	}
+	return budget
+}
+
+// whois sends a query to a whois server and returns its response
+func (cfg domainConfig) whois(ctx context.Context, server, query string, timeout time.Duration) (string, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "43")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", server)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if _, err = fmt.Fprintf(conn, "%s\r\n", query); err != nil {
+		return "", err
+	}
+
+	response, err := io.ReadAll(io.LimitReader(conn, maxWHOISResponse))
+	if err != nil {
+		return "", err
+	}
+	return string(response), nil
+}
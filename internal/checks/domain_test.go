@@ -0,0 +1,264 @@
+package checks
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+// rdapFixture is a local stand-in rdap server knowing the registrations in expires
+func rdapFixture(t *testing.T, expires map[string]time.Time) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		domain := strings.TrimPrefix(r.URL.Path, "/domain/")
+		date, ok := expires[domain]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/rdap+json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"ldhName": domain,
+			"events": []any{
+				map[string]any{"eventAction": "registration", "eventDate": "2001-02-03T04:05:06Z"},
+				map[string]any{"eventAction": "expiration", "eventDate": date.Format(time.RFC3339)},
+			},
+			"entities": []any{
+				map[string]any{
+					"roles":      []any{"registrar"},
+					"vcardArray": []any{"vcard", []any{[]any{"version", map[string]any{}, "text", "4.0"}, []any{"fn", map[string]any{}, "text", "Example Registrar, Inc."}}},
+				},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// whoisFixture is a local stand-in whois server answering each query from responses
+func whoisFixture(t *testing.T, responses map[string]string) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				query, err := bufio.NewReader(conn).ReadString('\n')
+				if err != nil {
+					return
+				}
+				response, ok := responses[strings.TrimSpace(query)]
+				if !ok {
+					response = "No match for \"" + strings.TrimSpace(query) + "\".\r\n"
+				}
+				_, _ = conn.Write([]byte(response))
+			}(conn)
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestDomainCheck(t *testing.T) {
+	now := time.Now().UTC()
+	rdap := rdapFixture(t, map[string]time.Time{
+		"example.test":  now.Add(100 * 24 * time.Hour),
+		"soon.test":     now.Add(10 * 24 * time.Hour),
+		"lapsing.test":  now.Add(3 * 24 * time.Hour),
+		"lapsed.test":   now.Add(-24 * time.Hour),
+		"ignored.test":  now.Add(100 * 24 * time.Hour),
+		"override.test": now.Add(40 * 24 * time.Hour),
+	})
+	whois := whoisFixture(t, map[string]string{
+		"fallback.test": "Domain Name: FALLBACK.TEST\r\nRegistrar: Whois Registrar Ltd\r\nRegistry Expiry Date: " +
+			now.Add(200*24*time.Hour).Format("2006-01-02T15:04:05Z") + "\r\n",
+		"nodate.test": "Domain Name: NODATE.TEST\r\nStatus: active\r\n",
+	})
+
+	tests := []struct {
+		name          string
+		host          models.Host
+		config        models.ServiceConfig
+		wantStatus    string
+		wantClass     string
+		wantRegistrar string
+		wantSource    string
+		wantDays      float64
+	}{
+		{
+			name:          "rdap",
+			host:          models.Host{URL: "https://www.example.test"},
+			config:        models.ServiceConfig{},
+			wantStatus:    "healthy",
+			wantRegistrar: "Example Registrar, Inc.",
+			wantSource:    rdap.URL,
+			wantDays:      99,
+		},
+		{
+			name:       "rdap expiring soon",
+			config:     models.ServiceConfig{"domain": "soon.test"},
+			wantStatus: "warning",
+			wantSource: rdap.URL,
+		},
+		{
+			name:       "rdap expiring within problem days",
+			config:     models.ServiceConfig{"domain": "lapsing.test"},
+			wantStatus: "problem",
+			wantSource: rdap.URL,
+		},
+		{
+			name:       "rdap expired",
+			config:     models.ServiceConfig{"domain": "lapsed.test"},
+			wantStatus: "problem",
+			wantSource: rdap.URL,
+		},
+		{
+			name:       "thresholds from the config",
+			config:     models.ServiceConfig{"domain": "override.test", "warning_days": 60, "problem_days": 45},
+			wantStatus: "problem",
+			wantSource: rdap.URL,
+		},
+		{
+			name:          "whois fallback",
+			config:        models.ServiceConfig{"domain": "fallback.test"},
+			wantStatus:    "healthy",
+			wantRegistrar: "Whois Registrar Ltd",
+			wantSource:    "whois://" + whois,
+			wantDays:      199,
+		},
+		{
+			name:       "no expiration date anywhere",
+			config:     models.ServiceConfig{"domain": "nodate.test"},
+			wantStatus: "problem",
+			wantClass:  ErrorClassUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config["rdap_server"] = rdap.URL
+			tt.config["whois_server"] = whois
+			tt.config["timeout"] = 5
+
+			r := domainChecker{}.Check(context.Background(), tt.host, models.HostService{Config: tt.config})
+
+			if r.Status != tt.wantStatus || r.ErrorClass != tt.wantClass {
+				t.Fatalf("got %s (%q): %s, expected %s (%q)", r.Status, r.ErrorClass, r.Message, tt.wantStatus, tt.wantClass)
+			}
+			if tt.wantSource != "" && !strings.HasPrefix(r.Details["source"], tt.wantSource) {
+				t.Errorf("got source %q, expected %q", r.Details["source"], tt.wantSource)
+			}
+			if tt.wantRegistrar != "" && r.Details["registrar"] != tt.wantRegistrar {
+				t.Errorf("got registrar %q, expected %q", r.Details["registrar"], tt.wantRegistrar)
+			}
+			if tt.wantDays != 0 && r.Metrics["days_until_expiration"] != tt.wantDays {
+				t.Errorf("got %v days until expiration, expected %v", r.Metrics["days_until_expiration"], tt.wantDays)
+			}
+			if strings.HasPrefix(tt.wantSource, "whois://") && !strings.Contains(r.Details["rdap_error"], "404") {
+				t.Errorf("got rdap error %q, expected the failed rdap query", r.Details["rdap_error"])
+			}
+			if tt.wantClass != "" && (!strings.Contains(r.Message, "rdap:") || !strings.Contains(r.Message, "whois:")) {
+				t.Errorf("message %q does not give both errors", r.Message)
+			}
+		})
+	}
+}
+
+func TestQueryWHOISDateLayouts(t *testing.T) {
+	tests := []struct {
+		line string
+		want time.Time
+	}{
+		{line: "Registry Expiry Date: 2031-08-13T04:00:00Z", want: time.Date(2031, 8, 13, 4, 0, 0, 0, time.UTC)},
+		{line: "Registrar Registration Expiration Date: 2031-08-13T04:00:00+02:00", want: time.Date(2031, 8, 13, 2, 0, 0, 0, time.UTC)},
+		{line: "Expiration Date: 2031-08-13 04:00:00", want: time.Date(2031, 8, 13, 4, 0, 0, 0, time.UTC)},
+		{line: "expires: 2031-08-13", want: time.Date(2031, 8, 13, 0, 0, 0, 0, time.UTC)},
+		{line: "paid-till: 2031.08.13", want: time.Date(2031, 8, 13, 0, 0, 0, 0, time.UTC)},
+		{line: "Expiry date: 13-Aug-2031", want: time.Date(2031, 8, 13, 0, 0, 0, 0, time.UTC)},
+		{line: "Renewal date: 13.08.2031", want: time.Date(2031, 8, 13, 0, 0, 0, 0, time.UTC)},
+		{line: "Expires On: 2031/08/13", want: time.Date(2031, 8, 13, 0, 0, 0, 0, time.UTC)},
+	}
+
+	responses := make(map[string]string, len(tests))
+	for i, tt := range tests {
+		responses[string(rune('a'+i))+".test"] = "% comment\r\n" + tt.line + "\r\n"
+	}
+	cfg := domainConfig{WHOISServer: whoisFixture(t, responses), Timeout: 5}
+
+	for i, tt := range tests {
+		reg, err := cfg.queryWHOIS(context.Background(), string(rune('a'+i))+".test")
+		if err != nil {
+			t.Errorf("%q: %s", tt.line, err)
+			continue
+		}
+		if !reg.expires.Equal(tt.want) {
+			t.Errorf("%q: got %s, expected %s", tt.line, reg.expires, tt.want)
+		}
+	}
+
+	if _, err := cfg.queryWHOIS(context.Background(), "missing.test"); err == nil {
+		t.Errorf("expected an error for a response without an expiration date")
+	}
+}
+
+func TestDomainCheckSharesTimeout(t *testing.T) {
+	// an rdap server that never answers must leave time for the whois fallback
+	rdap := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer rdap.Close()
+	whois := whoisFixture(t, map[string]string{
+		"slow.test": "Registry Expiry Date: " + time.Now().UTC().Add(100*24*time.Hour).Format("2006-01-02T15:04:05Z") + "\r\n",
+	})
+
+	hs := models.HostService{Config: models.ServiceConfig{"domain": "slow.test", "rdap_server": rdap.URL, "whois_server": whois, "timeout": 2}}
+
+	start := time.Now()
+	r := domainChecker{}.Check(context.Background(), models.Host{}, hs)
+	elapsed := time.Since(start)
+
+	if r.Status != "healthy" || !strings.HasPrefix(r.Details["source"], "whois://") {
+		t.Fatalf("got %s from %q: %s, expected the whois fallback", r.Status, r.Details["source"], r.Message)
+	}
+	if elapsed > 1500*time.Millisecond {
+		t.Errorf("took %s, expected rdap to get at most half of the 2s timeout", elapsed)
+	}
+}
+
+func TestDomainQueryBudget(t *testing.T) {
+	cfg := domainConfig{Timeout: 20}
+
+	if got := cfg.queryBudget(context.Background(), 2); got != 20*time.Second {
+		t.Errorf("without a deadline got %s, expected the whole timeout", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if got := cfg.queryBudget(ctx, 2); got > 5*time.Second || got < 4*time.Second {
+		t.Errorf("got %s, expected half of the 10s left", got)
+	}
+	if got := cfg.queryBudget(ctx, 1); got > 10*time.Second || got < 9*time.Second {
+		t.Errorf("got %s, expected the 10s left", got)
+	}
+}
@@ -0,0 +1,58 @@
+package checks
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/textproto"
+)
+
+// Error classes stored on models.CheckResult
+const (
+	ErrorClassTimeout    = "timeout"
+	ErrorClassDNS        = "dns"
+	ErrorClassConnection = "connection"
+	ErrorClassTLS        = "tls"
+	ErrorClassProtocol   = "protocol"
+	ErrorClassAssertion  = "assertion"
+	ErrorClassConfig     = "config"
+	ErrorClassUnknown    = "unknown"
+)
+
+// classifyError maps an error returned while running a check onto an error class
+func classifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var recordErr tls.RecordHeaderError
+	var verifyErr *tls.CertificateVerificationError
+	var unknownAuthErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	var opErr *net.OpError
+	var replyErr *textproto.Error
+	var protocolErr textproto.ProtocolError
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassTimeout
+	case errors.As(err, &dnsErr):
+		return ErrorClassDNS
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ErrorClassTimeout
+	case errors.As(err, &recordErr), errors.As(err, &verifyErr), errors.As(err, &unknownAuthErr),
+		errors.As(err, &hostnameErr), errors.As(err, &invalidErr):
+		return ErrorClassTLS
+	case errors.As(err, &replyErr), errors.As(err, &protocolErr):
+		return ErrorClassProtocol
+	case errors.As(err, &opErr):
+		return ErrorClassConnection
+	}
+
+	return ErrorClassUnknown
+}
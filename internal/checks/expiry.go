@@ -0,0 +1,71 @@
+package checks
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/namhuydao/vigilate/internal/certificateutils"
+)
+
+// default expiry thresholds in days, used when the site preferences do not set them
+const (
+	defaultSSLWarningDays = 30
+	defaultSSLProblemDays = 7
+)
+
+// expiryThresholds turns a certificate expiring soon warning or problem
+type expiryThresholds struct {
+	WarningDays int `json:"warning_days"`
+	ProblemDays int `json:"problem_days"`
+}
+
+// defaultExpiryThresholds returns the thresholds set in the site preferences
+func defaultExpiryThresholds() expiryThresholds {
+	return expiryThresholds{
+		WarningDays: preferenceInt("ssl_expiry_warning_days", defaultSSLWarningDays),
+		ProblemDays: preferenceInt("ssl_expiry_problem_days", defaultSSLProblemDays),
+	}
+}
+
+// expiryParams describes the expiry threshold parameters of a certificate
+func expiryParams() []Param {
+	return expiryParamsOf("certificate", 0, 0)
+}
+
+// expiryParamsOf describes the expiry threshold parameters of subject; thresholds
+// without a default of their own default to the site setting
+func expiryParamsOf(subject string, warningDays, problemDays int) []Param {
+	param := func(name, description string, days int) Param {
+		p := Param{Name: name, Type: "int", Description: fmt.Sprintf(description, subject)}
+		if days > 0 {
+			p.Default = strconv.Itoa(days)
+		} else {
+			p.Description += ", defaults to the site setting"
+		}
+		return p
+	}
+
+	return []Param{
+		param("warning_days", "warn when the %s expires in fewer days", warningDays),
+		param("problem_days", "report a problem when the %s expires in fewer days", problemDays),
+	}
+}
+
+// status returns the status of a certificate by its expiry; an expired
+// certificate is always a problem
+func (t expiryThresholds) status(cd certificateutils.CertificateDetails) string {
+	return t.statusIn(cd.DaysUntilExpiration, cd.Expired)
+}
+
+// statusIn returns the status of anything expiring in days, such as a domain
+// registration; expired is always a problem
+func (t expiryThresholds) statusIn(days int, expired bool) string {
+	switch {
+	case expired || days < t.ProblemDays:
+		return "problem"
+	case days < t.WarningDays:
+		return "warning"
+	default:
+		return "healthy"
+	}
+}
@@ -0,0 +1,177 @@
+package checks
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func init() {
+	Register(grpcChecker{})
+}
+
+// grpcChecker calls the standard grpc.health.v1.Health/Check method of the host
+type grpcChecker struct{}
+
+// grpcConfig holds the parameters of a grpc check
+type grpcConfig struct {
+	latencyThresholds
+	Port               int               `json:"port"`
+	Service            string            `json:"service"`
+	TLS                bool              `json:"tls"`
+	ServerName         string            `json:"server_name"`
+	CAFile             string            `json:"ca_file"`
+	InsecureSkipVerify bool              `json:"insecure_skip_verify"`
+	Metadata           map[string]string `json:"metadata"`
+	Timeout            int               `json:"timeout"`
+}
+
+// status of each serving status of the health checking protocol
+var grpcServingStatus = map[healthpb.HealthCheckResponse_ServingStatus]string{
+	healthpb.HealthCheckResponse_SERVING:         "healthy",
+	healthpb.HealthCheckResponse_NOT_SERVING:     "problem",
+	healthpb.HealthCheckResponse_UNKNOWN:         "warning",
+	healthpb.HealthCheckResponse_SERVICE_UNKNOWN: "problem",
+}
+
+func (grpcChecker) Kind() string { return "grpc" }
+
+func (grpcChecker) Name() string { return "gRPC" }
+
+func (grpcChecker) Icon() string { return "fas fa-project-diagram" }
+
+func (grpcChecker) Params() []Param {
+	params := []Param{
+		{Name: "port", Type: "int", Description: "port of the grpc server (required)"},
+		{Name: "service", Type: "string", Description: "service to check, empty for the server as a whole"},
+		{Name: "tls", Type: "bool", Default: "false", Description: "connect with tls instead of plaintext"},
+		{Name: "server_name", Type: "string", Description: "name the server certificate is verified against, defaults to the host name of the url"},
+		{Name: "ca_file", Type: "string", Description: "PEM file of CA certificates trusted instead of the system roots"},
+		{Name: "insecure_skip_verify", Type: "bool", Default: "false", Description: "skip verification of the server certificate"},
+		{Name: "metadata", Type: "map", Description: `metadata sent with the call, e.g. {"authorization": "Bearer token"}`},
+		{Name: "timeout", Type: "int", Default: "5", Description: "seconds to wait for the answer"},
+	}
+	return append(params, latencyParams()...)
+}
+
+func (grpcChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := grpcConfig{Timeout: 5}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+
+	if cfg.Port <= 0 || cfg.Port > 65535 {
+		return models.CheckResult{Status: "problem", Message: "no valid port configured", ErrorClass: ErrorClassConfig}
+	}
+
+	creds, err := cfg.credentials(h)
+	if err != nil {
+		return models.CheckResult{Status: "problem", Message: err.Error(), ErrorClass: ErrorClassConfig}
+	}
+
+	address := net.JoinHostPort(hostAddress(h), strconv.Itoa(cfg.Port))
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(creds), grpc.WithUserAgent("vigilate"))
+	if err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("%s - %s", address, err), ErrorClass: ErrorClassConfig}
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+	if len(cfg.Metadata) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(cfg.Metadata))
+	}
+
+	start := time.Now()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: cfg.Service})
+	elapsed := time.Since(start)
+
+	target := address
+	if cfg.Service != "" {
+		target = fmt.Sprintf("%s %s", address, cfg.Service)
+	}
+
+	if err != nil {
+		return models.CheckResult{
+			Status:     "problem",
+			Message:    fmt.Sprintf("%s - %s", target, status.Convert(err).Message()),
+			Duration:   elapsed,
+			ErrorClass: classifyGRPCError(err),
+			Details:    map[string]string{"code": status.Code(err).String()},
+		}
+	}
+
+	servingStatus := resp.GetStatus()
+	r := models.CheckResult{
+		Status:   grpcServingStatus[servingStatus],
+		Message:  fmt.Sprintf("%s - %s", target, servingStatus),
+		Duration: elapsed,
+		Details:  map[string]string{"serving_status": servingStatus.String()},
+		Metrics:  map[string]float64{"response_ms": float64(elapsed) / float64(time.Millisecond)},
+	}
+	if r.Status == "" {
+		r.Status = "warning"
+	}
+	if r.Status != "healthy" {
+		r.ErrorClass = ErrorClassAssertion
+	}
+
+	cfg.apply(&r)
+	return r
+}
+
+// credentials returns the transport credentials of the connection, plaintext unless tls is set
+func (cfg grpcConfig) credentials(h models.Host) (credentials.TransportCredentials, error) {
+	if !cfg.TLS {
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsConfig := &tls.Config{ServerName: cfg.ServerName, InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = hostName(h)
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// classifyGRPCError maps the status code of a failed call onto an error class
+func classifyGRPCError(err error) string {
+	switch status.Code(err) {
+	case codes.DeadlineExceeded:
+		return ErrorClassTimeout
+	case codes.Unavailable:
+		return ErrorClassConnection
+	case codes.Unimplemented, codes.Unauthenticated, codes.PermissionDenied:
+		return ErrorClassProtocol
+	case codes.NotFound:
+		return ErrorClassConfig
+	}
+	return classifyError(err)
+}
@@ -0,0 +1,69 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func init() {
+	Register(heartbeatChecker{})
+}
+
+// heartbeatChecker expects an external job to ping the push url of the host service
+type heartbeatChecker struct{}
+
+// heartbeatConfig holds the parameters of a heartbeat check
+type heartbeatConfig struct {
+	Period int `json:"period"`
+	Grace  int `json:"grace"`
+}
+
+func (heartbeatChecker) Kind() string { return "heartbeat" }
+
+func (heartbeatChecker) Name() string { return "Heartbeat" }
+
+func (heartbeatChecker) Icon() string { return "fas fa-heartbeat" }
+
+func (heartbeatChecker) Params() []Param {
+	return []Param{
+		{Name: "period", Type: "int", Description: "seconds expected between pings, defaults to the schedule of the service"},
+		{Name: "grace", Type: "int", Default: "60", Description: "seconds a ping may be late before the service is problem"},
+	}
+}
+
+// Check reports an overdue heartbeat; otherwise the status set by the last ping stands
+func (c heartbeatChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	return pushedResult(c, hs, "waiting for the first heartbeat")
+}
+
+func (heartbeatChecker) Overdue(hs models.HostService, now time.Time) (models.CheckResult, bool) {
+	cfg := heartbeatConfig{Grace: 60}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}, true
+	}
+
+	// a heartbeat that never pinged stays pending rather than alerting
+	if neverPushed(hs) {
+		return models.CheckResult{}, false
+	}
+
+	period := time.Duration(cfg.Period) * time.Second
+	if period <= 0 {
+		period = scheduleInterval(hs)
+	}
+	grace := time.Duration(cfg.Grace) * time.Second
+
+	if !now.After(hs.LastPush.Add(period + grace)) {
+		return models.CheckResult{}, false
+	}
+
+	return models.CheckResult{
+		Status:     "problem",
+		Message:    fmt.Sprintf("no heartbeat since %s (expected every %s, grace %s)", hs.LastPush.Local().Format("2006-01-02 15:04:05"), period, grace),
+		ErrorClass: ErrorClassTimeout,
+		Details:    map[string]string{"last_push": hs.LastPush.Format(time.RFC3339)},
+	}, true
+}
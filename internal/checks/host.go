@@ -0,0 +1,35 @@
+package checks
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+// hostName returns the host name part of the host url, falling back to the host name
+func hostName(h models.Host) string {
+	raw := h.URL
+	if raw != "" && !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+
+	return h.HostName
+}
+
+// hostAddress returns the address used to reach a host directly: the ipv4
+// address if set, then the ipv6 address, then the host name
+func hostAddress(h models.Host) string {
+	if h.IP != "" {
+		return h.IP
+	}
+	if h.IPV6 != "" {
+		return h.IPV6
+	}
+	return hostName(h)
+}
@@ -0,0 +1,162 @@
+package checks
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func init() {
+	Register(httpChecker{kind: "http", name: "HTTP", icon: "fas fa-server", scheme: "http"})
+	Register(httpChecker{kind: "https", name: "HTTPS", icon: "fas fa-server", scheme: "https"})
+}
+
+// httpChecker requests the host url over http or https
+type httpChecker struct {
+	kind   string
+	name   string
+	icon   string
+	scheme string
+}
+
+// httpConfig holds the parameters of an http or https check
+type httpConfig struct {
+	httpRequestSpec
+	httpAssertions
+	latencyThresholds
+	Path         string `json:"path"`
+	Port         int    `json:"port"`
+	Timeout      int    `json:"timeout"`
+	MaxBodyBytes int64  `json:"max_body_bytes"`
+}
+
+// defaultMaxBodyBytes is the most of a response body read for assertions
+const defaultMaxBodyBytes = 1 << 20
+
+func (c httpChecker) Kind() string { return c.kind }
+
+func (c httpChecker) Name() string { return c.name }
+
+func (c httpChecker) Icon() string { return c.icon }
+
+func (c httpChecker) Params() []Param {
+	params := []Param{
+		{Name: "path", Type: "string", Description: "path requested instead of the one in the host url"},
+		{Name: "port", Type: "int", Description: "port requested instead of the one in the host url"},
+		{Name: "timeout", Type: "int", Default: "10", Description: "seconds to wait for a response"},
+	}
+	params = append(params, requestParams()...)
+	params = append(params, assertionParams()...)
+	return append(params, latencyParams()...)
+}
+
+func (c httpChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := httpConfig{Timeout: 10, MaxBodyBytes: defaultMaxBodyBytes}
+	cfg.ExpectedStatus = []statusRange{{min: http.StatusOK, max: http.StatusOK}}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+
+	target, err := c.targetURL(h.URL, cfg)
+	if err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("%s - %s", h.URL, err), ErrorClass: ErrorClassConfig}
+	}
+
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client, err := cfg.newClient(timeout, nil)
+	if err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("%s - %s", target, err), ErrorClass: ErrorClassConfig}
+	}
+
+	req, err := cfg.newRequest(ctx, target)
+	if err != nil {
+		return models.CheckResult{
+			Status:     "problem",
+			Message:    fmt.Sprintf("%s - %s", target, err),
+			ErrorClass: ErrorClassConfig,
+		}
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return models.CheckResult{
+			Status:     "problem",
+			Message:    fmt.Sprintf("%s - %s", target, "error connecting"),
+			Duration:   time.Since(start),
+			ErrorClass: classifyError(err),
+			Details:    map[string]string{"error": err.Error()},
+		}
+	}
+
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(resp.Body)
+
+	r := models.CheckResult{
+		Message:    fmt.Sprintf("%s - %s", target, resp.Status),
+		Duration:   time.Since(start),
+		StatusCode: resp.StatusCode,
+	}
+
+	if resp.TLS != nil {
+		r.TLSVersion = tls.VersionName(resp.TLS.Version)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, cfg.MaxBodyBytes))
+	if err != nil {
+		r.Status = "problem"
+		r.Message = fmt.Sprintf("%s - error reading body: %s", target, err)
+		r.ErrorClass = classifyError(err)
+		return r
+	}
+	r.Metrics = map[string]float64{"body_bytes": float64(len(body))}
+
+	if err = cfg.check(resp, body); err != nil {
+		r.Status = "problem"
+		r.Message = fmt.Sprintf("%s - %s - %s assertion failed", target, resp.Status, assertionName(err))
+		r.Details = map[string]string{"assertion": err.Error()}
+		r.ErrorClass = ErrorClassAssertion
+		return r
+	}
+
+	r.Status = "healthy"
+	cfg.apply(&r)
+	return r
+}
+
+// targetURL builds the url to request from the host url and the check config
+func (c httpChecker) targetURL(hostURL string, cfg httpConfig) (string, error) {
+	if !strings.Contains(hostURL, "://") {
+		hostURL = c.scheme + "://" + hostURL
+	}
+
+	u, err := url.Parse(strings.TrimSuffix(hostURL, "/"))
+	if err != nil {
+		return "", err
+	}
+	u.Scheme = c.scheme
+
+	if cfg.Port > 0 {
+		u.Host = net.JoinHostPort(u.Hostname(), strconv.Itoa(cfg.Port))
+	}
+
+	if cfg.Path != "" {
+		u.Path = "/" + strings.TrimPrefix(cfg.Path, "/")
+		u.RawPath = ""
+	}
+
+	return u.String(), nil
+}
@@ -0,0 +1,167 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func init() {
+	Register(imapChecker{})
+}
+
+// imapChecker greets an imap server, optionally upgrading with STARTTLS and logging in
+type imapChecker struct{}
+
+func (imapChecker) Kind() string { return "imap" }
+
+func (imapChecker) Name() string { return "IMAP" }
+
+func (imapChecker) Icon() string { return "fas fa-inbox" }
+
+func (imapChecker) Params() []Param {
+	return mailParams("143", "993")
+}
+
+func (imapChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := defaultMailConfig()
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+	if err := cfg.checkPasswordEnv(); err != nil {
+		return models.CheckResult{Status: "problem", Message: err.Error(), ErrorClass: ErrorClassConfig}
+	}
+
+	address := cfg.address(h, 143, 993)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	s, err := cfg.dial(ctx, h, address)
+	if err != nil {
+		return mailFailure(address, start, err)
+	}
+	defer s.close()
+
+	greeting, err := s.text.ReadLine()
+	if err == nil && !strings.HasPrefix(greeting, "* OK") {
+		err = textproto.ProtocolError(greeting)
+	}
+	if err != nil {
+		return mailFailure(address, start, err)
+	}
+
+	capabilities, err := imapConverse(ctx, s, cfg)
+	elapsed := time.Since(start)
+	if err != nil {
+		return mailFailure(address, start, err)
+	}
+
+	r := models.CheckResult{
+		Status:   "healthy",
+		Message:  fmt.Sprintf("%s - %s", address, strings.TrimPrefix(greeting, "* ")),
+		Duration: elapsed,
+		Details:  map[string]string{"greeting": greeting, "capabilities": strings.Join(capabilities, " ")},
+		Metrics:  map[string]float64{"session_ms": float64(elapsed) / float64(time.Millisecond)},
+	}
+	if cfg.User != "" {
+		r.Message = fmt.Sprintf("%s - logged in as %s", address, cfg.User)
+	}
+
+	cfg.apply(&r)
+	cfg.certificate(&r, s)
+	return r
+}
+
+// imapConverse upgrades with STARTTLS and logs in as configured, returning the
+// capabilities of the server
+func imapConverse(ctx context.Context, s *mailSession, cfg mailConfig) ([]string, error) {
+	capabilities, err := imapCapabilities(s)
+	if err != nil {
+		return nil, err
+	}
+
+	upgrade, err := s.wantTLS(cfg.StartTLS, hasCapability(capabilities, "STARTTLS"))
+	if err != nil {
+		return capabilities, err
+	}
+	if upgrade {
+		if _, err = imapCommand(s, "a2", "STARTTLS"); err != nil {
+			return capabilities, err
+		}
+		if err = s.startTLS(ctx); err != nil {
+			return capabilities, err
+		}
+		if capabilities, err = imapCapabilities(s); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.User != "" {
+		if err = s.canLogin(); err != nil {
+			return capabilities, err
+		}
+		if hasCapability(capabilities, "LOGINDISABLED") {
+			return capabilities, fmt.Errorf("login disabled by the server")
+		}
+		if _, err = imapCommand(s, "a3", "LOGIN %s %s", imapQuote(cfg.User), imapQuote(cfg.password())); err != nil {
+			return capabilities, err
+		}
+	}
+
+	_, _ = imapCommand(s, "a4", "LOGOUT")
+	return capabilities, nil
+}
+
+// imapCommand sends a tagged command and returns the untagged lines of the
+// reply, failing unless the tagged status is OK
+func imapCommand(s *mailSession, tag, format string, args ...any) ([]string, error) {
+	if err := s.text.PrintfLine(tag+" "+format, args...); err != nil {
+		return nil, err
+	}
+
+	var untagged []string
+	for {
+		line, err := s.text.ReadLine()
+		if err != nil {
+			return untagged, err
+		}
+
+		status, found := strings.CutPrefix(line, tag+" ")
+		if !found {
+			untagged = append(untagged, line)
+			continue
+		}
+		if !strings.HasPrefix(strings.ToUpper(status), "OK") {
+			return untagged, textproto.ProtocolError(status)
+		}
+		return untagged, nil
+	}
+}
+
+// imapCapabilities asks the server for its capabilities
+func imapCapabilities(s *mailSession) ([]string, error) {
+	lines, err := imapCommand(s, "a1", "CAPABILITY")
+	if err != nil {
+		return nil, err
+	}
+
+	var capabilities []string
+	for _, line := range lines {
+		if rest, found := strings.CutPrefix(line, "* CAPABILITY "); found {
+			capabilities = append(capabilities, strings.Fields(rest)...)
+		}
+	}
+
+	return capabilities, nil
+}
+
+// imapQuote writes s as an imap quoted string
+func imapQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
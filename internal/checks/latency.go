@@ -0,0 +1,40 @@
+package checks
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+// latencyThresholds turns a healthy but slow result warning or problem
+type latencyThresholds struct {
+	WarningMs  int `json:"warning_ms"`
+	CriticalMs int `json:"critical_ms"`
+}
+
+// latencyParams describes the latency threshold parameters
+func latencyParams() []Param {
+	return []Param{
+		{Name: "warning_ms", Type: "int", Description: "latency in milliseconds above which the service is warning"},
+		{Name: "critical_ms", Type: "int", Description: "latency in milliseconds above which the service is problem"},
+	}
+}
+
+// apply downgrades a healthy result whose duration is over a threshold
+func (t latencyThresholds) apply(r *models.CheckResult) {
+	if r.Status != "healthy" {
+		return
+	}
+
+	latency := r.Duration.Round(time.Millisecond)
+
+	switch {
+	case t.CriticalMs > 0 && r.Duration > time.Duration(t.CriticalMs)*time.Millisecond:
+		r.Status = "problem"
+		r.Message = fmt.Sprintf("%s - slow response: %s (critical above %dms)", r.Message, latency, t.CriticalMs)
+	case t.WarningMs > 0 && r.Duration > time.Duration(t.WarningMs)*time.Millisecond:
+		r.Status = "warning"
+		r.Message = fmt.Sprintf("%s - slow response: %s (warning above %dms)", r.Message, latency, t.WarningMs)
+	}
+}
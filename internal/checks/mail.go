@@ -0,0 +1,264 @@
+package checks
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/certificateutils"
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+var (
+	// errNoStartTLS is returned when STARTTLS is required but the server does not offer it
+	errNoStartTLS = errors.New("STARTTLS required but not offered")
+	// errLoginWithoutTLS is returned instead of sending credentials over a plaintext connection
+	errLoginWithoutTLS = errors.New("refusing to log in without tls")
+	// errLoginUntrusted is returned instead of sending credentials to a server whose certificate did not verify
+	errLoginUntrusted = errors.New("refusing to log in, the server certificate is not trusted")
+)
+
+// mailConfig holds the parameters shared by the mail server checks
+type mailConfig struct {
+	latencyThresholds
+	expiryThresholds
+	loginConfig
+	Port               int    `json:"port"`
+	TLS                bool   `json:"tls"`
+	StartTLS           string `json:"starttls"`
+	ServerName         string `json:"server_name"`
+	CAFile             string `json:"ca_file"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+	Timeout            int    `json:"timeout"`
+}
+
+// defaultMailConfig returns the defaults of the mail server checks
+func defaultMailConfig() mailConfig {
+	return mailConfig{expiryThresholds: defaultExpiryThresholds(), StartTLS: "optional", Timeout: 10}
+}
+
+// mailParams describes the parameters shared by the mail server checks
+func mailParams(port, tlsPort string) []Param {
+	params := []Param{
+		{Name: "port", Type: "int", Default: port, Description: "port to connect to, " + tlsPort + " when tls is set"},
+		{Name: "tls", Type: "bool", Default: "false", Description: "connect with implicit tls instead of plaintext"},
+		{Name: "starttls", Type: "string", Default: "optional", Description: "one of optional, required, none; optional upgrades when the server offers it. Credentials are only sent over tls with a verified certificate"},
+		{Name: "server_name", Type: "string", Description: "name the server certificate is verified against, defaults to the host name of the url"},
+		{Name: "ca_file", Type: "string", Description: "PEM file of CA certificates trusted instead of the system roots"},
+		{Name: "insecure_skip_verify", Type: "bool", Default: "false", Description: "skip verification of the server certificate, also before logging in"},
+		{Name: "timeout", Type: "int", Default: "10", Description: "seconds for the whole session"},
+	}
+	params = append(params, loginParams()...)
+	params = append(params, expiryParams()...)
+	return append(params, latencyParams()...)
+}
+
+// address returns the host and port of the mail server
+func (cfg mailConfig) address(h models.Host, port, tlsPort int) string {
+	switch {
+	case cfg.Port > 0:
+		port = cfg.Port
+	case cfg.TLS:
+		port = tlsPort
+	}
+	return net.JoinHostPort(hostAddress(h), strconv.Itoa(port))
+}
+
+// mailSession is a connection to a mail server speaking a line based protocol
+type mailSession struct {
+	conn       net.Conn
+	text       *textproto.Conn
+	address    string
+	serverName string
+	opts       certificateutils.VerifyOptions
+	timeout    time.Duration
+	cert       *certificateutils.CertificateDetails
+	// insecure allows logging in when the certificate did not verify
+	insecure bool
+}
+
+// dial connects to the mail server, with implicit tls when configured; the whole
+// session must end by the deadline of ctx
+func (cfg mailConfig) dial(ctx context.Context, h models.Host, address string) (*mailSession, error) {
+	switch cfg.StartTLS {
+	case "optional", "required", "none":
+	default:
+		return nil, fmt.Errorf("invalid starttls %q", cfg.StartTLS)
+	}
+
+	s := &mailSession{
+		address:    address,
+		serverName: cfg.ServerName,
+		timeout:    time.Duration(cfg.Timeout) * time.Second,
+		insecure:   cfg.InsecureSkipVerify,
+	}
+	if s.serverName == "" {
+		s.serverName = hostName(h)
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		s.opts.RootCAs = x509.NewCertPool()
+		if !s.opts.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	s.conn = conn
+	s.text = textproto.NewConn(conn)
+
+	if cfg.TLS {
+		if err = s.startTLS(ctx); err != nil {
+			s.close()
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// startTLS runs the tls handshake on the connection and reads the certificate
+// of the server; verification is left to certificate so its failures are reported
+func (s *mailSession) startTLS(ctx context.Context) error {
+	tlsConn := tls.Client(s.conn, &tls.Config{InsecureSkipVerify: true, ServerName: s.serverName})
+	if err := tlsConn.Handshake(); err != nil {
+		return fmt.Errorf("tls handshake error: %w", err)
+	}
+
+	cd, err := certificateutils.DetailsFromConnectionState(ctx, tlsConn.ConnectionState(), s.address, s.serverName, s.opts, s.timeout)
+	if err != nil {
+		return err
+	}
+
+	s.conn = tlsConn
+	s.text = textproto.NewConn(tlsConn)
+	s.cert = &cd
+	return nil
+}
+
+// wantTLS reports whether the session should be upgraded with STARTTLS
+func (s *mailSession) wantTLS(mode string, offered bool) (bool, error) {
+	if s.cert != nil || mode == "none" {
+		return false, nil
+	}
+	if !offered && mode == "required" {
+		return false, errNoStartTLS
+	}
+	return offered, nil
+}
+
+// canLogin returns an error unless credentials may be sent over the session, which
+// needs tls with a certificate that verified, or insecure_skip_verify
+func (s *mailSession) canLogin() error {
+	if s.cert == nil {
+		return errLoginWithoutTLS
+	}
+	if !s.insecure && !(s.cert.ChainVerified && s.cert.HostnameVerified) {
+		return errLoginUntrusted
+	}
+	return nil
+}
+
+// cmd sends an smtp style command and reads its reply, which must have expectCode
+func (s *mailSession) cmd(expectCode int, format string, args ...any) (int, string, error) {
+	id, err := s.text.Cmd(format, args...)
+	if err != nil {
+		return 0, "", err
+	}
+	s.text.StartResponse(id)
+	defer s.text.EndResponse(id)
+
+	return s.text.ReadResponse(expectCode)
+}
+
+func (s *mailSession) close() {
+	_ = s.text.Close()
+}
+
+// mailFailure returns the result of a mail session that failed with err
+func mailFailure(address string, start time.Time, err error) models.CheckResult {
+	class := classifyError(err)
+	switch {
+	case errors.Is(err, errNoStartTLS), errors.Is(err, errLoginUntrusted):
+		class = ErrorClassTLS
+	case errors.Is(err, errLoginWithoutTLS):
+		class = ErrorClassConfig
+	}
+
+	return models.CheckResult{
+		Status:     "problem",
+		Message:    fmt.Sprintf("%s - %s", address, err),
+		Duration:   time.Since(start),
+		ErrorClass: class,
+	}
+}
+
+// certificate adds the certificate of a tls session to a result, which turns
+// warning or problem when it expires soon or cannot be verified
+func (cfg mailConfig) certificate(r *models.CheckResult, s *mailSession) {
+	if s.cert == nil {
+		r.Details["tls"] = "none"
+		return
+	}
+
+	cd := *s.cert
+	r.TLSVersion = cd.TLSVersion
+	r.Details["tls"] = cd.TLSVersion
+	r.Details["subject"] = cd.SubjectName
+	r.Details["issuer"] = cd.IssuerName
+	r.Details["expiration_date"] = cd.ExpirationDate
+	r.Metrics["days_until_expiration"] = float64(cd.DaysUntilExpiration)
+
+	if status := cfg.expiryThresholds.status(cd); status != "healthy" {
+		r.Status = worseStatus(r.Status, status)
+		if cd.Expired {
+			r.Message = fmt.Sprintf("%s; certificate has expired", r.Message)
+		} else {
+			r.Message = fmt.Sprintf("%s; certificate expiring in %d days", r.Message, cd.DaysUntilExpiration)
+		}
+		r.ErrorClass = ErrorClassTLS
+	}
+
+	if cfg.InsecureSkipVerify {
+		return
+	}
+	if !cd.ChainVerified {
+		r.Status = "problem"
+		r.Message = fmt.Sprintf("%s; chain not trusted: %s", r.Message, cd.ChainError)
+		r.ErrorClass = ErrorClassTLS
+	}
+	if !cd.HostnameVerified {
+		r.Status = "problem"
+		r.Message = fmt.Sprintf("%s; %s", r.Message, cd.HostnameError)
+		r.ErrorClass = ErrorClassTLS
+	}
+}
+
+// hasCapability reports whether a capability is among capabilities, ignoring case
+func hasCapability(capabilities []string, capability string) bool {
+	for _, c := range capabilities {
+		if strings.EqualFold(c, capability) {
+			return true
+		}
+	}
+	return false
+}
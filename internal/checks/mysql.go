@@ -0,0 +1,62 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func init() {
+	Register(mysqlChecker{})
+}
+
+// mysqlChecker logs in to a mysql or mariadb server and runs a query
+type mysqlChecker struct{}
+
+// mysqlConfig holds the parameters of a mysql check
+type mysqlConfig struct {
+	databaseConfig
+	TLS string `json:"tls"`
+}
+
+func (mysqlChecker) Kind() string { return "mysql" }
+
+func (mysqlChecker) Name() string { return "MySQL" }
+
+func (mysqlChecker) Icon() string { return "fas fa-database" }
+
+func (mysqlChecker) Params() []Param {
+	params := []Param{
+		{Name: "tls", Type: "string", Default: "preferred", Description: "one of false, preferred, true, skip-verify"},
+	}
+	return append(params, databaseParams("3306", "SELECT 1")...)
+}
+
+func (mysqlChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := mysqlConfig{databaseConfig: databaseConfig{Port: 3306, Query: "SELECT 1", Timeout: 5}, TLS: "preferred"}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+	if err := cfg.checkPasswordEnv(); err != nil {
+		return models.CheckResult{Status: "problem", Message: err.Error(), ErrorClass: ErrorClassConfig}
+	}
+
+	if cfg.User == "" {
+		return models.CheckResult{Status: "problem", Message: "no user configured", ErrorClass: ErrorClassConfig}
+	}
+
+	address := cfg.address(h)
+	dsn := mysql.NewConfig()
+	dsn.User = cfg.User
+	dsn.Passwd = cfg.password()
+	dsn.Net = "tcp"
+	dsn.Addr = address
+	dsn.DBName = cfg.Database
+	dsn.TLSConfig = cfg.TLS
+
+	return cfg.run(ctx, address, func(ctx context.Context) (string, error) {
+		return querySQL(ctx, "mysql", dsn.FormatDSN(), cfg.Query)
+	})
+}
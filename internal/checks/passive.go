@@ -0,0 +1,68 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func init() {
+	Register(passiveChecker{})
+}
+
+// passiveChecker holds results a system computes itself and submits over the api
+type passiveChecker struct{}
+
+// passiveConfig holds the parameters of a passive check
+type passiveConfig struct {
+	MaxAge      int    `json:"max_age"`
+	StaleStatus string `json:"stale_status"`
+}
+
+func (passiveChecker) Kind() string { return "passive" }
+
+func (passiveChecker) Name() string { return "Passive" }
+
+func (passiveChecker) Icon() string { return "fas fa-inbox" }
+
+func (passiveChecker) Params() []Param {
+	return []Param{
+		{Name: "max_age", Type: "int", Default: "0", Description: "seconds after the last submitted result before it is stale, 0 never goes stale"},
+		{Name: "stale_status", Type: "string", Default: "problem", Description: "status of a stale service, warning or problem"},
+	}
+}
+
+// Check reports a stale result; otherwise the last submitted result stands
+func (c passiveChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	return pushedResult(c, hs, "waiting for the first result")
+}
+
+func (passiveChecker) Overdue(hs models.HostService, now time.Time) (models.CheckResult, bool) {
+	cfg := passiveConfig{StaleStatus: "problem"}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}, true
+	}
+
+	if cfg.MaxAge <= 0 || neverPushed(hs) {
+		return models.CheckResult{}, false
+	}
+
+	maxAge := time.Duration(cfg.MaxAge) * time.Second
+	if !now.After(hs.LastPush.Add(maxAge)) {
+		return models.CheckResult{}, false
+	}
+
+	status := cfg.StaleStatus
+	if status != "warning" {
+		status = "problem"
+	}
+
+	return models.CheckResult{
+		Status:     status,
+		Message:    fmt.Sprintf("no result submitted since %s (max age %s)", hs.LastPush.Local().Format("2006-01-02 15:04:05"), maxAge),
+		ErrorClass: ErrorClassTimeout,
+		Details:    map[string]string{"last_push": hs.LastPush.Format(time.RFC3339)},
+	}, true
+}
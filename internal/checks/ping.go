@@ -0,0 +1,293 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+func init() {
+	Register(pingChecker{})
+}
+
+// pingChecker sends icmp echo requests to the host
+type pingChecker struct{}
+
+// pingConfig holds the parameters of a ping check
+type pingConfig struct {
+	latencyThresholds
+	Count              int `json:"count"`
+	IntervalMs         int `json:"interval_ms"`
+	Timeout            int `json:"timeout"`
+	PayloadSize        int `json:"payload_size"`
+	WarningLossPercent int `json:"warning_loss_percent"`
+	ProblemLossPercent int `json:"problem_loss_percent"`
+}
+
+// pingSocket describes the icmp socket used for one address family
+type pingSocket struct {
+	network     string
+	rawNetwork  string
+	address     string
+	protocol    int
+	requestType icmp.Type
+	replyType   icmp.Type
+}
+
+// pingChecks counts the ping checks run, giving each its own icmp id so concurrent
+// checks of one address on raw sockets do not take each other's replies
+var pingChecks atomic.Uint32
+
+var (
+	pingSocketV4 = pingSocket{network: "udp4", rawNetwork: "ip4:icmp", address: "0.0.0.0", protocol: 1, requestType: ipv4.ICMPTypeEcho, replyType: ipv4.ICMPTypeEchoReply}
+	pingSocketV6 = pingSocket{network: "udp6", rawNetwork: "ip6:ipv6-icmp", address: "::", protocol: 58, requestType: ipv6.ICMPTypeEchoRequest, replyType: ipv6.ICMPTypeEchoReply}
+)
+
+func (pingChecker) Kind() string { return "ping" }
+
+func (pingChecker) Name() string { return "Ping" }
+
+func (pingChecker) Icon() string { return "fas fa-satellite-dish" }
+
+func (pingChecker) Params() []Param {
+	params := []Param{
+		{Name: "count", Type: "int", Default: "4", Description: "echo requests sent"},
+		{Name: "interval_ms", Type: "int", Default: "200", Description: "milliseconds between echo requests"},
+		{Name: "timeout", Type: "int", Default: "2", Description: "seconds to wait for each reply"},
+		{Name: "payload_size", Type: "int", Default: "56", Description: "bytes of data in each echo request"},
+		{Name: "warning_loss_percent", Type: "int", Default: "20", Description: "packet loss at or above which the service is warning"},
+		{Name: "problem_loss_percent", Type: "int", Default: "60", Description: "packet loss at or above which the service is problem"},
+	}
+	return append(params, latencyParams()...)
+}
+
+func (pingChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := pingConfig{Count: 4, IntervalMs: 200, Timeout: 2, PayloadSize: 56, WarningLossPercent: 20, ProblemLossPercent: 60}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+
+	if err := cfg.validate(ctx); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+
+	target, err := net.DefaultResolver.LookupIPAddr(ctx, hostAddress(h))
+	if err != nil || len(target) == 0 {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("%s - %s", hostAddress(h), err), ErrorClass: classifyError(err)}
+	}
+	ip := target[0].IP
+
+	socket := pingSocketV4
+	if ip.To4() == nil {
+		socket = pingSocketV6
+	}
+
+	conn, privileged, err := socket.listen()
+	if err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("cannot open icmp socket: %s", err), ErrorClass: ErrorClassConfig}
+	}
+	defer conn.Close()
+
+	var dst net.Addr = &net.UDPAddr{IP: ip}
+	if privileged {
+		dst = &net.IPAddr{IP: ip}
+	}
+
+	id := (os.Getpid() + int(pingChecks.Add(1))) & 0xffff
+	payload := make([]byte, max(cfg.PayloadSize, 0))
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+
+	var rtts []time.Duration
+	sent := 0
+	for seq := 1; seq <= cfg.Count; seq++ {
+		if seq > 1 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Duration(cfg.IntervalMs) * time.Millisecond):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		sent++
+		rtt, err := socket.echo(ctx, conn, dst, id, seq, payload, privileged, time.Duration(cfg.Timeout)*time.Second)
+		if err == nil {
+			rtts = append(rtts, rtt)
+		}
+	}
+
+	received := len(rtts)
+	loss := packetLoss(sent, received)
+
+	r := models.CheckResult{
+		Metrics: map[string]float64{
+			"packets_sent":     float64(sent),
+			"packets_received": float64(received),
+			"packet_loss":      loss,
+		},
+		Details: map[string]string{"address": ip.String()},
+	}
+
+	if sent == 0 {
+		r.Status = "problem"
+		r.Message = fmt.Sprintf("%s - check timed out before an echo request was sent", ip)
+		r.ErrorClass = ErrorClassTimeout
+		return r
+	}
+
+	if received == 0 {
+		r.Status = "problem"
+		r.Message = fmt.Sprintf("%s - no reply to %d echo requests", ip, sent)
+		r.ErrorClass = ErrorClassTimeout
+		return r
+	}
+
+	minRTT, maxRTT, sum := rtts[0], rtts[0], time.Duration(0)
+	for _, rtt := range rtts {
+		minRTT = min(minRTT, rtt)
+		maxRTT = max(maxRTT, rtt)
+		sum += rtt
+	}
+	avgRTT := sum / time.Duration(received)
+
+	r.Duration = avgRTT
+	r.Metrics["rtt_min_ms"] = float64(minRTT) / float64(time.Millisecond)
+	r.Metrics["rtt_avg_ms"] = float64(avgRTT) / float64(time.Millisecond)
+	r.Metrics["rtt_max_ms"] = float64(maxRTT) / float64(time.Millisecond)
+	r.Message = fmt.Sprintf("%s - %d/%d replies, %.0f%% loss, rtt min/avg/max %s/%s/%s", ip, received, sent, loss,
+		minRTT.Round(time.Microsecond), avgRTT.Round(time.Microsecond), maxRTT.Round(time.Microsecond))
+
+	r.Status = cfg.lossStatus(loss)
+
+	cfg.apply(&r)
+	return r
+}
+
+// validate checks the config, including that the echo requests can all be sent
+// before the check times out
+func (cfg pingConfig) validate(ctx context.Context) error {
+	if cfg.Count <= 0 {
+		return fmt.Errorf("count must be positive, not %d", cfg.Count)
+	}
+	if cfg.IntervalMs < 0 {
+		return fmt.Errorf("interval_ms must not be negative, not %d", cfg.IntervalMs)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		schedule := time.Duration(cfg.Count) * time.Duration(cfg.IntervalMs) * time.Millisecond
+		if budget := time.Until(deadline); schedule >= budget {
+			return fmt.Errorf("%d echo requests every %dms do not fit in the check timeout of %s", cfg.Count, cfg.IntervalMs, budget.Round(time.Second))
+		}
+	}
+
+	return nil
+}
+
+// packetLoss returns the percentage of the sent echo requests that got no reply
+func packetLoss(sent, received int) float64 {
+	if sent == 0 {
+		return 0
+	}
+	return float64(sent-received) / float64(sent) * 100
+}
+
+// lossStatus returns the status for a packet loss percentage
+func (cfg pingConfig) lossStatus(loss float64) string {
+	switch {
+	case cfg.ProblemLossPercent > 0 && loss >= float64(cfg.ProblemLossPercent):
+		return "problem"
+	case cfg.WarningLossPercent > 0 && loss >= float64(cfg.WarningLossPercent):
+		return "warning"
+	default:
+		return "healthy"
+	}
+}
+
+// listen opens an unprivileged icmp datagram socket, falling back to a raw socket
+// when the system does not allow them; privileged reports a raw socket
+func (s pingSocket) listen() (*icmp.PacketConn, bool, error) {
+	conn, err := icmp.ListenPacket(s.network, s.address)
+	if err == nil {
+		return conn, false, nil
+	}
+
+	conn, rawErr := icmp.ListenPacket(s.rawNetwork, s.address)
+	if rawErr != nil {
+		return nil, false, fmt.Errorf("%s; %s", err, rawErr)
+	}
+
+	return conn, true, nil
+}
+
+// echo sends one echo request and waits for its reply, returning the round trip time
+func (s pingSocket) echo(ctx context.Context, conn *icmp.PacketConn, dst net.Addr, id, seq int, payload []byte, privileged bool, timeout time.Duration) (time.Duration, error) {
+	msg := icmp.Message{
+		Type: s.requestType,
+		Body: &icmp.Echo{ID: id, Seq: seq, Data: payload},
+	}
+	b, err := msg.Marshal(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err = conn.SetReadDeadline(deadline); err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	if _, err = conn.WriteTo(b, dst); err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			return 0, err
+		}
+		rtt := time.Since(start)
+
+		reply, err := icmp.ParseMessage(s.protocol, buf[:n])
+		if err != nil || reply.Type != s.replyType {
+			continue
+		}
+
+		echo, ok := reply.Body.(*icmp.Echo)
+		if !ok || echo.Seq != seq || !addrIP(peer).Equal(addrIP(dst)) {
+			continue
+		}
+
+		// the kernel rewrites the id of datagram sockets, so only raw sockets can match it
+		if privileged && echo.ID != id {
+			continue
+		}
+
+		return rtt, nil
+	}
+}
+
+// addrIP returns the ip address of a socket address
+func addrIP(addr net.Addr) net.IP {
+	switch a := addr.(type) {
+	case *net.UDPAddr:
+		return a.IP
+	case *net.IPAddr:
+		return a.IP
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package checks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func TestPacketLoss(t *testing.T) {
+	tests := []struct {
+		sent     int
+		received int
+		want     float64
+	}{
+		{sent: 4, received: 4, want: 0},
+		{sent: 4, received: 3, want: 25},
+		{sent: 4, received: 0, want: 100},
+		{sent: 5, received: 2, want: 60},
+		// stopped early by the check timeout: the unsent requests are not lost
+		{sent: 2, received: 2, want: 0},
+		{sent: 0, received: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := packetLoss(tt.sent, tt.received); got != tt.want {
+			t.Errorf("%d/%d: got %v%% loss, expected %v%%", tt.received, tt.sent, got, tt.want)
+		}
+	}
+}
+
+func TestPingLossStatus(t *testing.T) {
+	cfg := pingConfig{WarningLossPercent: 20, ProblemLossPercent: 60}
+
+	tests := []struct {
+		cfg  pingConfig
+		loss float64
+		want string
+	}{
+		{cfg: cfg, loss: 0, want: "healthy"},
+		{cfg: cfg, loss: 19.9, want: "healthy"},
+		{cfg: cfg, loss: 20, want: "warning"},
+		{cfg: cfg, loss: 59.9, want: "warning"},
+		{cfg: cfg, loss: 60, want: "problem"},
+		{cfg: cfg, loss: 100, want: "problem"},
+		{cfg: pingConfig{ProblemLossPercent: 50}, loss: 25, want: "healthy"},
+		{cfg: pingConfig{WarningLossPercent: 10}, loss: 100, want: "warning"},
+		{cfg: pingConfig{}, loss: 100, want: "healthy"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.cfg.lossStatus(tt.loss); got != tt.want {
+			t.Errorf("%+v at %v%% loss: got %s, expected %s", tt.cfg, tt.loss, got, tt.want)
+		}
+	}
+}
+
+func TestPingCheckRejectsConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config models.ServiceConfig
+	}{
+		{name: "no echo requests", config: models.ServiceConfig{"count": 0}},
+		{name: "negative interval", config: models.ServiceConfig{"interval_ms": -1}},
+		{name: "schedule longer than the check", config: models.ServiceConfig{"count": 20, "interval_ms": 100}},
+	}
+
+	for _, tt := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		r := pingChecker{}.Check(ctx, models.Host{HostName: "127.0.0.1"}, models.HostService{Config: tt.config})
+		cancel()
+
+		if r.Status != "problem" || r.ErrorClass != ErrorClassConfig {
+			t.Errorf("%s: got %s (%q): %s, expected a config problem", tt.name, r.Status, r.ErrorClass, r.Message)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func init() {
+	Register(pop3Checker{})
+}
+
+// pop3Checker greets a pop3 server, optionally upgrading with STLS and logging in
+type pop3Checker struct{}
+
+func (pop3Checker) Kind() string { return "pop3" }
+
+func (pop3Checker) Name() string { return "POP3" }
+
+func (pop3Checker) Icon() string { return "fas fa-envelope-open" }
+
+func (pop3Checker) Params() []Param {
+	return mailParams("110", "995")
+}
+
+func (pop3Checker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := defaultMailConfig()
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+	if err := cfg.checkPasswordEnv(); err != nil {
+		return models.CheckResult{Status: "problem", Message: err.Error(), ErrorClass: ErrorClassConfig}
+	}
+
+	address := cfg.address(h, 110, 995)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	s, err := cfg.dial(ctx, h, address)
+	if err != nil {
+		return mailFailure(address, start, err)
+	}
+	defer s.close()
+
+	greeting, err := pop3Reply(s)
+	if err != nil {
+		return mailFailure(address, start, err)
+	}
+
+	capabilities, err := pop3Converse(ctx, s, cfg)
+	elapsed := time.Since(start)
+	if err != nil {
+		return mailFailure(address, start, err)
+	}
+
+	r := models.CheckResult{
+		Status:   "healthy",
+		Message:  fmt.Sprintf("%s - %s", address, greeting),
+		Duration: elapsed,
+		Details:  map[string]string{"greeting": greeting, "capabilities": strings.Join(capabilities, ", ")},
+		Metrics:  map[string]float64{"session_ms": float64(elapsed) / float64(time.Millisecond)},
+	}
+	if cfg.User != "" {
+		r.Message = fmt.Sprintf("%s - logged in as %s", address, cfg.User)
+	}
+
+	cfg.apply(&r)
+	cfg.certificate(&r, s)
+	return r
+}
+
+// pop3Converse upgrades with STLS and logs in as configured, returning the
+// capabilities of the server
+func pop3Converse(ctx context.Context, s *mailSession, cfg mailConfig) ([]string, error) {
+	capabilities := pop3Capabilities(s)
+
+	upgrade, err := s.wantTLS(cfg.StartTLS, hasCapability(capabilities, "STLS"))
+	if err != nil {
+		return capabilities, err
+	}
+	if upgrade {
+		if _, err = pop3Command(s, "STLS"); err != nil {
+			return capabilities, err
+		}
+		if err = s.startTLS(ctx); err != nil {
+			return capabilities, err
+		}
+		capabilities = pop3Capabilities(s)
+	}
+
+	if cfg.User != "" {
+		if err = s.canLogin(); err != nil {
+			return capabilities, err
+		}
+		if _, err = pop3Command(s, "USER %s", cfg.User); err != nil {
+			return capabilities, err
+		}
+		if _, err = pop3Command(s, "PASS %s", cfg.password()); err != nil {
+			return capabilities, err
+		}
+	}
+
+	_, _ = pop3Command(s, "QUIT")
+	return capabilities, nil
+}
+
+// pop3Command sends a command and returns the text of its +OK reply
+func pop3Command(s *mailSession, format string, args ...any) (string, error) {
+	if err := s.text.PrintfLine(format, args...); err != nil {
+		return "", err
+	}
+	return pop3Reply(s)
+}
+
+// pop3Reply reads a reply, failing unless it is +OK
+func pop3Reply(s *mailSession) (string, error) {
+	line, err := s.text.ReadLine()
+	if err != nil {
+		return "", err
+	}
+
+	text, found := strings.CutPrefix(line, "+OK")
+	if !found {
+		return "", textproto.ProtocolError(line)
+	}
+	return strings.TrimSpace(text), nil
+}
+
+// pop3Capabilities asks the server for its capabilities; servers without CAPA have none
+func pop3Capabilities(s *mailSession) []string {
+	if _, err := pop3Command(s, "CAPA"); err != nil {
+		return nil
+	}
+
+	lines, err := s.text.ReadDotLines()
+	if err != nil {
+		return nil
+	}
+
+	capabilities := make([]string, 0, len(lines))
+	for _, line := range lines {
+		name, _, _ := strings.Cut(line, " ")
+		capabilities = append(capabilities, strings.ToUpper(name))
+	}
+	return capabilities
+}
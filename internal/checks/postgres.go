@@ -0,0 +1,63 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func init() {
+	Register(postgresChecker{})
+}
+
+// postgresChecker logs in to a postgres server and runs a query
+type postgresChecker struct{}
+
+// postgresConfig holds the parameters of a postgres check
+type postgresConfig struct {
+	databaseConfig
+	SSLMode string `json:"sslmode"`
+}
+
+func (postgresChecker) Kind() string { return "postgres" }
+
+func (postgresChecker) Name() string { return "PostgreSQL" }
+
+func (postgresChecker) Icon() string { return "fas fa-database" }
+
+func (postgresChecker) Params() []Param {
+	params := []Param{
+		{Name: "sslmode", Type: "string", Default: "prefer", Description: "one of disable, prefer, require, verify-ca, verify-full"},
+	}
+	return append(params, databaseParams("5432", "SELECT 1")...)
+}
+
+func (postgresChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := postgresConfig{databaseConfig: databaseConfig{Port: 5432, Database: "postgres", Query: "SELECT 1", Timeout: 5}, SSLMode: "prefer"}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+	if err := cfg.checkPasswordEnv(); err != nil {
+		return models.CheckResult{Status: "problem", Message: err.Error(), ErrorClass: ErrorClassConfig}
+	}
+
+	if cfg.User == "" {
+		return models.CheckResult{Status: "problem", Message: "no user configured", ErrorClass: ErrorClassConfig}
+	}
+
+	address := cfg.address(h)
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.password()),
+		Host:     address,
+		Path:     "/" + cfg.Database,
+		RawQuery: url.Values{"sslmode": {cfg.SSLMode}, "application_name": {"vigilate"}}.Encode(),
+	}
+
+	return cfg.run(ctx, address, func(ctx context.Context) (string, error) {
+		return querySQL(ctx, "pgx", dsn.String(), cfg.Query)
+	})
+}
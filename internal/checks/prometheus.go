@@ -0,0 +1,478 @@
+package checks
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func init() {
+	Register(prometheusChecker{})
+}
+
+// defaultMaxScrapeBytes is the most of a metrics page read
+const defaultMaxScrapeBytes = 10 << 20
+
+// prometheusChecker scrapes a prometheus text format endpoint and compares one metric with thresholds
+type prometheusChecker struct{}
+
+// prometheusConfig holds the parameters of a prometheus check
+type prometheusConfig struct {
+	httpRequestSpec
+	latencyThresholds
+	URL          string            `json:"url"`
+	Path         string            `json:"path"`
+	Metric       string            `json:"metric"`
+	Labels       map[string]string `json:"labels"`
+	Aggregate    string            `json:"aggregate"`
+	Rate         bool              `json:"rate"`
+	Warning      string            `json:"warning"`
+	Problem      string            `json:"problem"`
+	MaxBodyBytes int64             `json:"max_body_bytes"`
+	Timeout      int               `json:"timeout"`
+}
+
+// promSample is a value of a metric read at a time; series identifies what was
+// scraped so a changed config does not compute a rate across two different series
+type promSample struct {
+	series string
+	value  float64
+	at     time.Time
+}
+
+// previous samples of the host services checking a rate, by host service id; they
+// live in memory only, so a rate is known again from the second scrape after a start
+var (
+	promMu      sync.Mutex
+	promSamples = make(map[int]promSample)
+)
+
+// promScrape is what was read of a metrics page
+type promScrape struct {
+	values       []float64
+	skipped      int
+	firstSkipped error
+}
+
+// ForgetRateSample drops the previous sample of a host service, once it is no longer
+// checked or its config changed
+func ForgetRateSample(hostServiceID int) {
+	promMu.Lock()
+	delete(promSamples, hostServiceID)
+	promMu.Unlock()
+}
+
+// promThreshold is a comparison such as "> 100" applied to the scraped value
+type promThreshold struct {
+	op    string
+	value float64
+}
+
+// operators of a threshold, longest first so >= is not read as >
+var promOperators = []string{">=", "<=", "==", "!=", ">", "<"}
+
+func (prometheusChecker) Kind() string { return "prometheus" }
+
+func (prometheusChecker) Name() string { return "Prometheus Metric" }
+
+func (prometheusChecker) Icon() string { return "fas fa-chart-line" }
+
+func (prometheusChecker) Params() []Param {
+	params := []Param{
+		{Name: "url", Type: "string", Description: "url of the metrics page, defaults to the host url"},
+		{Name: "path", Type: "string", Default: "/metrics", Description: "path requested when no url is set"},
+		{Name: "metric", Type: "string", Description: "name of the metric"},
+		{Name: "labels", Type: "map", Description: `labels the series must have, e.g. {"code": "500"}`},
+		{Name: "aggregate", Type: "string", Default: "sum", Description: "how the matching series are combined: sum, min, max, avg or count"},
+		{Name: "rate", Type: "bool", Default: "false", Description: "compare the per second rate of the value between two scrapes, such as for counters; the rate is only known from the second scrape after a start or a config change, and the service stays healthy until then"},
+		{Name: "warning", Type: "string", Description: "expression that turns the service warning, e.g. > 100; operators are >, >=, <, <=, == and !="},
+		{Name: "problem", Type: "string", Description: "expression that turns the service problem, e.g. > 500"},
+		{Name: "max_body_bytes", Type: "int", Default: "10485760", Description: "most bytes of the metrics page read"},
+		{Name: "timeout", Type: "int", Default: "10", Description: "seconds to wait for the metrics page"},
+	}
+	params = append(params, requestParams()...)
+	return append(params, latencyParams()...)
+}
+
+func (prometheusChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := prometheusConfig{Aggregate: "sum", MaxBodyBytes: defaultMaxScrapeBytes, Timeout: 10}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+
+	if cfg.Metric == "" {
+		return models.CheckResult{Status: "problem", Message: "no metric configured", ErrorClass: ErrorClassConfig}
+	}
+	if _, ok := promAggregates[cfg.Aggregate]; !ok {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("unknown aggregate %q", cfg.Aggregate), ErrorClass: ErrorClassConfig}
+	}
+
+	warning, err := parsePromThreshold(cfg.Warning)
+	if err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid warning: %s", err), ErrorClass: ErrorClassConfig}
+	}
+	problem, err := parsePromThreshold(cfg.Problem)
+	if err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid problem: %s", err), ErrorClass: ErrorClassConfig}
+	}
+
+	target := cfg.URL
+	if target == "" {
+		scheme := "http"
+		if strings.HasPrefix(h.URL, "https://") {
+			scheme = "https"
+		}
+		path := cfg.Path
+		if path == "" {
+			path = "/metrics"
+		}
+		target, err = httpChecker{scheme: scheme}.targetURL(h.URL, httpConfig{Path: path})
+		if err != nil {
+			return models.CheckResult{Status: "problem", Message: fmt.Sprintf("%s - %s", h.URL, err), ErrorClass: ErrorClassConfig}
+		}
+	}
+
+	client, err := cfg.newClient(time.Duration(cfg.Timeout)*time.Second, nil)
+	if err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("%s - %s", target, err), ErrorClass: ErrorClassConfig}
+	}
+
+	req, err := cfg.newRequest(ctx, target)
+	if err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("%s - %s", target, err), ErrorClass: ErrorClassConfig}
+	}
+	req.Header.Set("Accept", "text/plain;version=0.0.4;q=1,*/*;q=0.1")
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return models.CheckResult{
+			Status:     "problem",
+			Message:    fmt.Sprintf("%s - error connecting", target),
+			Duration:   time.Since(start),
+			ErrorClass: classifyError(err),
+			Details:    map[string]string{"error": err.Error()},
+		}
+	}
+	defer resp.Body.Close()
+
+	r := models.CheckResult{
+		StatusCode: resp.StatusCode,
+		Details:    map[string]string{"metric": cfg.Metric},
+		Metrics:    map[string]float64{},
+	}
+	if resp.TLS != nil {
+		r.TLSVersion = tls.VersionName(resp.TLS.Version)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		r.Status = "problem"
+		r.Message = fmt.Sprintf("%s - %s", target, resp.Status)
+		r.Duration = time.Since(start)
+		r.ErrorClass = ErrorClassProtocol
+		return r
+	}
+
+	scrape, err := scrapeMetric(io.LimitReader(resp.Body, cfg.MaxBodyBytes), cfg.Metric, cfg.Labels)
+	scraped := time.Now()
+	r.Duration = scraped.Sub(start)
+	if err != nil {
+		r.Status = "problem"
+		r.Message = fmt.Sprintf("%s - invalid metrics page: %s", target, err)
+		r.ErrorClass = ErrorClassProtocol
+		return r
+	}
+	values := scrape.values
+	r.Metrics["series_matched"] = float64(len(values))
+	if scrape.skipped > 0 {
+		r.Metrics["lines_skipped"] = float64(scrape.skipped)
+		r.Details["skipped"] = scrape.firstSkipped.Error()
+	}
+
+	if len(values) == 0 {
+		r.Status = "problem"
+		r.Message = fmt.Sprintf("%s - no series of %s", target, promSelector(cfg.Metric, cfg.Labels))
+		r.ErrorClass = ErrorClassAssertion
+		return r
+	}
+
+	value := promAggregates[cfg.Aggregate](values)
+	r.Metrics[cfg.Metric] = value
+	name := promSelector(cfg.Metric, cfg.Labels)
+
+	if cfg.Rate {
+		series := fmt.Sprintf("%s %s %s", target, cfg.Aggregate, name)
+
+		promMu.Lock()
+		previous, seen := promSamples[hs.ID]
+		seen = seen && previous.series == series
+		interval := scraped.Sub(previous.at).Seconds()
+		// a sample taken no later than the previous one gives no rate, so the
+		// previous one is kept for the next scrape
+		if !seen || interval > 0 {
+			promSamples[hs.ID] = promSample{series: series, value: value, at: scraped}
+		}
+		promMu.Unlock()
+
+		if !seen || interval <= 0 {
+			r.Status = "healthy"
+			r.Message = fmt.Sprintf("%s = %s, the rate is known after the next scrape", name, formatPromValue(value))
+			cfg.apply(&r)
+			return r
+		}
+
+		// like prometheus, a counter that went down was reset and counts from zero
+		increase := value - previous.value
+		if increase < 0 {
+			increase = value
+		}
+		name = fmt.Sprintf("rate(%s)", name)
+		value = increase / interval
+		r.Metrics[cfg.Metric+"_rate"] = value
+	}
+
+	r.Status = "healthy"
+	r.Message = fmt.Sprintf("%s = %s", name, formatPromValue(value))
+	switch {
+	case problem != nil && problem.matches(value):
+		r.Status = "problem"
+		r.Message = fmt.Sprintf("%s (problem %s)", r.Message, cfg.Problem)
+		r.ErrorClass = ErrorClassAssertion
+	case warning != nil && warning.matches(value):
+		r.Status = "warning"
+		r.Message = fmt.Sprintf("%s (warning %s)", r.Message, cfg.Warning)
+		r.ErrorClass = ErrorClassAssertion
+	}
+
+	cfg.apply(&r)
+	return r
+}
+
+// promAggregates combine the values of the series matching a selector
+var promAggregates = map[string]func([]float64) float64{
+	"sum": sumValues,
+	"min": func(values []float64) float64 {
+		m := values[0]
+		for _, v := range values[1:] {
+			m = math.Min(m, v)
+		}
+		return m
+	},
+	"max": func(values []float64) float64 {
+		m := values[0]
+		for _, v := range values[1:] {
+			m = math.Max(m, v)
+		}
+		return m
+	},
+	"avg": func(values []float64) float64 {
+		return sumValues(values) / float64(len(values))
+	},
+	"count": func(values []float64) float64 {
+		return float64(len(values))
+	},
+}
+
+// sumValues adds up values
+func sumValues(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum
+}
+
+// parsePromThreshold reads an expression such as "> 100"; an empty expression is no threshold
+func parsePromThreshold(expr string) (*promThreshold, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, nil
+	}
+
+	for _, op := range promOperators {
+		if rest, ok := strings.CutPrefix(expr, op); ok {
+			value, err := strconv.ParseFloat(strings.TrimSpace(rest), 64)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a number", strings.TrimSpace(rest))
+			}
+			return &promThreshold{op: op, value: value}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%q does not start with one of %s", expr, strings.Join(promOperators, " "))
+}
+
+// matches reports whether value meets the threshold
+func (t promThreshold) matches(value float64) bool {
+	switch t.op {
+	case ">":
+		return value > t.value
+	case ">=":
+		return value >= t.value
+	case "<":
+		return value < t.value
+	case "<=":
+		return value <= t.value
+	case "==":
+		return value == t.value
+	case "!=":
+		return value != t.value
+	}
+	return false
+}
+
+// scrapeMetric reads a page in the prometheus text format and returns the values
+// of the series of metric having all the labels; lines that do not parse are
+// skipped and counted
+func scrapeMetric(page io.Reader, metric string, labels map[string]string) (promScrape, error) {
+	var scrape promScrape
+
+	lines := bufio.NewScanner(page)
+	lines.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; lines.Scan(); n++ {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, seriesLabels, value, err := parsePromLine(line)
+		if err != nil {
+			if scrape.skipped == 0 {
+				scrape.firstSkipped = fmt.Errorf("line %d: %w", n, err)
+			}
+			scrape.skipped++
+			continue
+		}
+		if name != metric {
+			continue
+		}
+
+		matched := true
+		for k, v := range labels {
+			if seriesLabels[k] != v {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			scrape.values = append(scrape.values, value)
+		}
+	}
+
+	return scrape, lines.Err()
+}
+
+// parsePromLine reads a sample line, written as name{label="value",...} value [timestamp]
+func parsePromLine(line string) (string, map[string]string, float64, error) {
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return "", nil, 0, errors.New("no value")
+	}
+	name, rest := line[:end], line[end:]
+
+	labels := make(map[string]string)
+	if strings.HasPrefix(rest, "{") {
+		var err error
+		rest, err = parsePromLabels(rest[1:], labels)
+		if err != nil {
+			return "", nil, 0, err
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 || len(fields) > 2 {
+		return "", nil, 0, fmt.Errorf("invalid sample %q", line)
+	}
+
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, 0, fmt.Errorf("invalid value %q", fields[0])
+	}
+	if len(fields) == 2 {
+		if _, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+			return "", nil, 0, fmt.Errorf("invalid timestamp %q", fields[1])
+		}
+	}
+
+	return name, labels, value, nil
+}
+
+// parsePromLabels reads the labels after the opening brace into labels and
+// returns what follows the closing brace
+func parsePromLabels(s string, labels map[string]string) (string, error) {
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if strings.HasPrefix(s, "}") {
+			return s[1:], nil
+		}
+
+		eq := strings.Index(s, "=")
+		if eq <= 0 {
+			return "", errors.New("invalid labels")
+		}
+		key := strings.TrimSpace(s[:eq])
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		if !strings.HasPrefix(s, `"`) {
+			return "", fmt.Errorf("label %s has no quoted value", key)
+		}
+
+		var value strings.Builder
+		i := 1
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+				continue
+			}
+			value.WriteByte(s[i])
+		}
+		if i == len(s) {
+			return "", fmt.Errorf("label %s is not terminated", key)
+		}
+		labels[key] = value.String()
+
+		s = strings.TrimLeft(s[i+1:], " \t")
+		s = strings.TrimPrefix(s, ",")
+	}
+}
+
+// promSelector writes a metric and its labels the way prometheus does, e.g. up{job="api"}
+func promSelector(metric string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return metric
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf("%s=%q", k, labels[k])
+	}
+	return metric + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatPromValue writes a value without needless digits
+func formatPromValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,211 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func TestParsePromLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		name    string
+		labels  map[string]string
+		value   float64
+		wantErr bool
+	}{
+		{line: "up 1", name: "up", labels: map[string]string{}, value: 1},
+		{line: `http_requests_total{method="post",code="200"} 1027 1395066363000`, name: "http_requests_total", labels: map[string]string{"method": "post", "code": "200"}, value: 1027},
+		{line: `http_request_duration_seconds_bucket{le="+Inf"} 144320`, name: "http_request_duration_seconds_bucket", labels: map[string]string{"le": "+Inf"}, value: 144320},
+		{line: `msdos_file_access_time_seconds{path="C:\\DIR\\FILE.TXT",error="Cannot find file:\n\"FILE.TXT\""} 1.458255915e9`, name: "msdos_file_access_time_seconds", labels: map[string]string{"path": `C:\DIR\FILE.TXT`, "error": "Cannot find file:\n\"FILE.TXT\""}, value: 1.458255915e9},
+		{line: `metric_without_timestamp_and_labels 12.47`, name: "metric_without_timestamp_and_labels", labels: map[string]string{}, value: 12.47},
+		{line: `something_weird{problem="division by zero"} +Inf -3982045`, name: "something_weird", labels: map[string]string{"problem": "division by zero"}, value: math.Inf(1)},
+		{line: `negative -Inf`, name: "negative", labels: map[string]string{}, value: math.Inf(-1)},
+		{line: `trailing_comma{a="1",} 2`, name: "trailing_comma", labels: map[string]string{"a": "1"}, value: 2},
+		{line: `spaced{ a = "1" , b="2" } 3`, name: "spaced", labels: map[string]string{"a": "1", "b": "2"}, value: 3},
+		{line: `empty{} 4`, name: "empty", labels: map[string]string{}, value: 4},
+		{line: `novalue`, wantErr: true},
+		{line: `novalue{a="1"}`, wantErr: true},
+		{line: `bad_value abc`, wantErr: true},
+		{line: `bad_timestamp 1 soon`, wantErr: true},
+		{line: `too_many 1 2 3`, wantErr: true},
+		{line: `unquoted{a=1} 1`, wantErr: true},
+		{line: `unterminated{a="1} 1`, wantErr: true},
+		{line: `{a="1"} 1`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		name, labels, value, err := parsePromLine(tt.line)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%q: expected an error", tt.line)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %s", tt.line, err)
+			continue
+		}
+		if name != tt.name || !reflect.DeepEqual(labels, tt.labels) || value != tt.value {
+			t.Errorf("%q: got (%q, %v, %v), expected (%q, %v, %v)", tt.line, name, labels, value, tt.name, tt.labels, tt.value)
+		}
+	}
+
+	_, _, value, err := parsePromLine("not_a_number NaN")
+	if err != nil || !math.IsNaN(value) {
+		t.Errorf("NaN: got %v, %v", value, err)
+	}
+}
+
+func TestParsePromLabels(t *testing.T) {
+	tests := []struct {
+		in      string
+		labels  map[string]string
+		rest    string
+		wantErr bool
+	}{
+		{in: `} 1`, labels: map[string]string{}, rest: " 1"},
+		{in: `a="x"} 1 2`, labels: map[string]string{"a": "x"}, rest: " 1 2"},
+		{in: `a="with } brace",b="with , comma"} 1`, labels: map[string]string{"a": "with } brace", "b": "with , comma"}, rest: " 1"},
+		{in: `a="quote \" backslash \\ newline \n"} 1`, labels: map[string]string{"a": "quote \" backslash \\ newline \n"}, rest: " 1"},
+		{in: `a=""} 1`, labels: map[string]string{"a": ""}, rest: " 1"},
+		{in: `a="x"`, wantErr: true},
+		{in: `="x"} 1`, wantErr: true},
+		{in: `a="x} 1`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		labels := make(map[string]string)
+		rest, err := parsePromLabels(tt.in, labels)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%q: expected an error", tt.in)
+			}
+			continue
+		}
+		if err != nil || rest != tt.rest || !reflect.DeepEqual(labels, tt.labels) {
+			t.Errorf("%q: got (%v, %q, %v), expected (%v, %q)", tt.in, labels, rest, err, tt.labels, tt.rest)
+		}
+	}
+}
+
+func TestParsePromThreshold(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    *promThreshold
+		wantErr bool
+	}{
+		{expr: "", want: nil},
+		{expr: "> 100", want: &promThreshold{op: ">", value: 100}},
+		{expr: ">=0.5", want: &promThreshold{op: ">=", value: 0.5}},
+		{expr: " <= -1 ", want: &promThreshold{op: "<=", value: -1}},
+		{expr: "!= 0", want: &promThreshold{op: "!=", value: 0}},
+		{expr: "== 1e3", want: &promThreshold{op: "==", value: 1000}},
+		{expr: "> lots", wantErr: true},
+		{expr: "100", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePromThreshold(tt.expr)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got (%v, %v), expected %v", tt.expr, got, err, tt.want)
+		}
+	}
+}
+
+func TestScrapeMetricSkipsMalformedLines(t *testing.T) {
+	page := strings.Join([]string{
+		"# HELP http_requests_total The total number of requests.",
+		"# TYPE http_requests_total counter",
+		`http_requests_total{code="200"} 1027`,
+		`http_requests_total{code="500} 3`,
+		`http_requests_total{code="500"} 3 1395066363000`,
+		`http_requests_total{code="200",path="/"} garbage`,
+		"",
+		"up 1",
+	}, "\n")
+
+	scrape, err := scrapeMetric(strings.NewReader(page), "http_requests_total", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(scrape.values, []float64{1027, 3}) {
+		t.Errorf("got values %v, expected [1027 3]", scrape.values)
+	}
+	if scrape.skipped != 2 || !strings.HasPrefix(scrape.firstSkipped.Error(), "line 4:") {
+		t.Errorf("got %d skipped, first %v, expected 2 from line 4", scrape.skipped, scrape.firstSkipped)
+	}
+}
+
+func TestPrometheusCheckRate(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		_, _ = fmt.Fprintf(w, "jobs_total %d\nnot a sample line\n", n*100)
+	}))
+	defer server.Close()
+
+	hs := models.HostService{ID: 9001, Config: models.ServiceConfig{"url": server.URL, "metric": "jobs_total", "rate": true}}
+	t.Cleanup(func() { ForgetRateSample(hs.ID) })
+	check := func() models.CheckResult {
+		return prometheusChecker{}.Check(context.Background(), models.Host{}, hs)
+	}
+
+	r := check()
+	if r.Status != "healthy" || !strings.Contains(r.Message, "known after the next scrape") {
+		t.Fatalf("first scrape: got %s: %s", r.Status, r.Message)
+	}
+	if r.Metrics["lines_skipped"] != 1 {
+		t.Errorf("got %v lines skipped, expected 1", r.Metrics["lines_skipped"])
+	}
+
+	// a previous sample from no earlier than this scrape gives no rate and is kept
+	future := time.Now().Add(time.Hour)
+	promMu.Lock()
+	previous := promSamples[hs.ID]
+	previous.at = future
+	promSamples[hs.ID] = previous
+	promMu.Unlock()
+
+	r = check()
+	if _, ok := r.Metrics["jobs_total_rate"]; ok || !strings.Contains(r.Message, "known after the next scrape") {
+		t.Fatalf("zero interval: got %s with metrics %v", r.Message, r.Metrics)
+	}
+	promMu.Lock()
+	kept := promSamples[hs.ID].at.Equal(future)
+	promSamples[hs.ID] = promSample{series: previous.series, value: 200, at: time.Now().Add(-10 * time.Second)}
+	promMu.Unlock()
+	if !kept {
+		t.Errorf("the previous sample was replaced after a zero interval")
+	}
+
+	r = check()
+	rate, ok := r.Metrics["jobs_total_rate"]
+	if !ok || math.IsInf(rate, 0) || math.IsNaN(rate) || rate < 9 || rate > 11 {
+		t.Fatalf("got rate %v (%s), expected about 10 per second", rate, r.Message)
+	}
+
+	// a changed config starts over instead of mixing two series
+	hs.Config["aggregate"] = "max"
+	r = check()
+	if _, ok = r.Metrics["jobs_total_rate"]; ok {
+		t.Errorf("changed config: got a rate %v, expected none", r.Metrics["jobs_total_rate"])
+	}
+
+	ForgetRateSample(hs.ID)
+	promMu.Lock()
+	_, remembered := promSamples[hs.ID]
+	promMu.Unlock()
+	if remembered {
+		t.Errorf("sample of host service %d not forgotten", hs.ID)
+	}
+}
@@ -0,0 +1,66 @@
+package checks
+
+import (
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+// PushChecker is implemented by check types whose results are pushed to vigilate
+// instead of polled. They are left out of the schedule; the sweeper asks them
+// instead whether a host service has gone without a result for too long
+type PushChecker interface {
+	Checker
+	// Overdue returns the result to record when no result arrived in time, and
+	// false when the host service is not overdue at now
+	Overdue(hs models.HostService, now time.Time) (models.CheckResult, bool)
+}
+
+// IsPush reports whether results of a kind are pushed instead of polled
+func IsPush(kind string) bool {
+	checker, ok := Get(kind)
+	if !ok {
+		return false
+	}
+
+	_, ok = checker.(PushChecker)
+	return ok
+}
+
+// pushedResult is the result of checking a push host service on demand: the
+// overdue result when it is overdue, pending before the first push, and
+// otherwise the status and message of the last push
+func pushedResult(c PushChecker, hs models.HostService, waiting string) models.CheckResult {
+	if r, late := c.Overdue(hs, time.Now()); late {
+		return r
+	}
+
+	if neverPushed(hs) {
+		return models.CheckResult{Status: "pending", Message: waiting}
+	}
+
+	return models.CheckResult{Status: hs.Status, Message: hs.LastMessage}
+}
+
+// neverPushed reports whether no result has been pushed for a host service yet;
+// last_push defaults to the first day of year one
+func neverPushed(hs models.HostService) bool {
+	return hs.LastPush.Year() <= 1
+}
+
+// scheduleInterval returns the interval a host service is scheduled at
+func scheduleInterval(hs models.HostService) time.Duration {
+	units := map[string]time.Duration{
+		"s": time.Second,
+		"m": time.Minute,
+		"h": time.Hour,
+		"d": 24 * time.Hour,
+	}
+
+	unit, ok := units[hs.ScheduleUnit]
+	if !ok {
+		unit = time.Minute
+	}
+
+	return time.Duration(hs.ScheduleNumber) * unit
+}
@@ -0,0 +1,143 @@
+package checks
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func init() {
+	Register(redisChecker{})
+}
+
+// redisChecker logs in to a redis server and runs a command
+type redisChecker struct{}
+
+// redisConfig holds the parameters of a redis check
+type redisConfig struct {
+	databaseConfig
+	Field string `json:"field"`
+	TLS   bool   `json:"tls"`
+}
+
+func (redisChecker) Kind() string { return "redis" }
+
+func (redisChecker) Name() string { return "Redis" }
+
+func (redisChecker) Icon() string { return "fas fa-layer-group" }
+
+func (redisChecker) Params() []Param {
+	params := []Param{
+		{Name: "field", Type: "string", Description: "field of an INFO reply to use as the value, e.g. master_last_io_seconds_ago for query INFO replication"},
+		{Name: "tls", Type: "bool", Default: "false", Description: "connect with tls"},
+	}
+	params = append(params, databaseParams("6379", "PING")...)
+	for i := range params {
+		switch params[i].Name {
+		case "database":
+			params[i].Description = "database number to select"
+		case "query":
+			params[i].Description = "command to run with its arguments separated by spaces"
+		}
+	}
+	return params
+}
+
+func (redisChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := redisConfig{databaseConfig: databaseConfig{Port: 6379, Query: "PING", Timeout: 5}}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+	if err := cfg.checkPasswordEnv(); err != nil {
+		return models.CheckResult{Status: "problem", Message: err.Error(), ErrorClass: ErrorClassConfig}
+	}
+
+	db := 0
+	if cfg.Database != "" {
+		n, err := strconv.Atoi(cfg.Database)
+		if err != nil {
+			return models.CheckResult{Status: "problem", Message: fmt.Sprintf("database %q is not a number", cfg.Database), ErrorClass: ErrorClassConfig}
+		}
+		db = n
+	}
+
+	command := strings.Fields(cfg.Query)
+	if len(command) == 0 {
+		return models.CheckResult{Status: "problem", Message: "no command configured", ErrorClass: ErrorClassConfig}
+	}
+	args := make([]any, len(command)-1)
+	for i, arg := range command[1:] {
+		args[i] = arg
+	}
+
+	address := cfg.address(h)
+	options := []redis.DialOption{
+		redis.DialDatabase(db),
+		redis.DialUseTLS(cfg.TLS),
+		redis.DialTLSConfig(&tls.Config{ServerName: hostName(h)}),
+		redis.DialClientName("vigilate"),
+	}
+	if cfg.User != "" {
+		options = append(options, redis.DialUsername(cfg.User))
+	}
+	if password := cfg.password(); password != "" {
+		options = append(options, redis.DialPassword(password))
+	}
+
+	return cfg.run(ctx, address, func(ctx context.Context) (string, error) {
+		conn, err := redis.DialContext(ctx, "tcp", address, options...)
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+
+		reply, err := redis.DoContext(conn, ctx, command[0], args...)
+		if err != nil {
+			return "", err
+		}
+
+		value := redisValue(reply)
+		if cfg.Field != "" {
+			return redisInfoField(value, cfg.Field)
+		}
+		return value, nil
+	})
+}
+
+// redisValue formats a redis reply as a string; the elements of an array reply
+// are separated by commas
+func redisValue(reply any) string {
+	switch v := reply.(type) {
+	case nil:
+		return "NULL"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	case []any:
+		values := make([]string, len(v))
+		for i, element := range v {
+			values[i] = redisValue(element)
+		}
+		return strings.Join(values, ",")
+	}
+	return fmt.Sprint(reply)
+}
+
+// redisInfoField returns a field of an INFO reply, written one field:value per line
+func redisInfoField(info, field string) (string, error) {
+	for _, line := range strings.Split(info, "\n") {
+		name, value, found := strings.Cut(strings.TrimSpace(line), ":")
+		if found && name == field {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("field %s not in the reply", field)
+}
@@ -0,0 +1,129 @@
+package checks
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultMaxRedirects is the number of redirects followed when none is configured
+const defaultMaxRedirects = 10
+
+// basicAuth holds credentials sent with http basic authentication
+type basicAuth struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// httpRequestSpec describes how the request of an http check is built and sent
+type httpRequestSpec struct {
+	Method             string            `json:"method"`
+	Headers            map[string]string `json:"headers"`
+	Body               string            `json:"body"`
+	BasicAuth          *basicAuth        `json:"basic_auth"`
+	BearerToken        string            `json:"bearer_token"`
+	FollowRedirects    *bool             `json:"follow_redirects"`
+	MaxRedirects       int               `json:"max_redirects"`
+	CAFile             string            `json:"ca_file"`
+	InsecureSkipVerify bool              `json:"insecure_skip_verify"`
+}
+
+// requestParams describes the request parameters shared by the http check types
+func requestParams() []Param {
+	return []Param{
+		{Name: "method", Type: "string", Default: "GET", Description: "http method"},
+		{Name: "headers", Type: "map", Description: `request headers, e.g. {"Host": "internal.example.com"}`},
+		{Name: "body", Type: "string", Description: "request body"},
+		{Name: "basic_auth", Type: "object", Description: `basic auth credentials, e.g. {"username": "u", "password": "p"}`},
+		{Name: "bearer_token", Type: "string", Description: "token sent in the Authorization header"},
+		{Name: "follow_redirects", Type: "bool", Default: "true", Description: "follow redirects"},
+		{Name: "max_redirects", Type: "int", Default: "10", Description: "most redirects followed"},
+		{Name: "ca_file", Type: "string", Description: "PEM file of CA certificates trusted instead of the system roots"},
+		{Name: "insecure_skip_verify", Type: "bool", Default: "false", Description: "skip verification of the server certificate"},
+	}
+}
+
+// newRequest builds the request for url
+func (s httpRequestSpec) newRequest(ctx context.Context, url string) (*http.Request, error) {
+	method := http.MethodGet
+	if s.Method != "" {
+		method = strings.ToUpper(s.Method)
+	}
+
+	var body io.Reader
+	if s.Body != "" {
+		body = strings.NewReader(s.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range s.Headers {
+		if strings.EqualFold(k, "Host") {
+			req.Host = v
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+
+	if s.BasicAuth != nil {
+		req.SetBasicAuth(s.BasicAuth.Username, s.BasicAuth.Password)
+	}
+
+	if s.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.BearerToken)
+	}
+
+	return req, nil
+}
+
+// newClient builds a dedicated client honouring the redirect and tls settings
+func (s httpRequestSpec) newClient(timeout time.Duration, jar http.CookieJar) (*http.Client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: s.InsecureSkipVerify}
+
+	if s.CAFile != "" {
+		pem, err := os.ReadFile(s.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", s.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	transport.DisableKeepAlives = true
+
+	maxRedirects := defaultMaxRedirects
+	if s.MaxRedirects > 0 {
+		maxRedirects = s.MaxRedirects
+	}
+	followRedirects := s.FollowRedirects == nil || *s.FollowRedirects
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		Jar:       jar,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if !followRedirects {
+				return http.ErrUseLastResponse
+			}
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		},
+	}, nil
+}
@@ -0,0 +1,190 @@
+package checks
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func init() {
+	Register(smtpChecker{})
+}
+
+// smtpChecker greets an smtp server, optionally upgrading with STARTTLS and logging in
+type smtpChecker struct{}
+
+// smtpConfig holds the parameters of an smtp check
+type smtpConfig struct {
+	mailConfig
+	HeloName     string   `json:"helo_name"`
+	Banner       string   `json:"banner"`
+	Capabilities []string `json:"capabilities"`
+}
+
+func (smtpChecker) Kind() string { return "smtp" }
+
+func (smtpChecker) Name() string { return "SMTP" }
+
+func (smtpChecker) Icon() string { return "fas fa-paper-plane" }
+
+func (smtpChecker) Params() []Param {
+	params := []Param{
+		{Name: "helo_name", Type: "string", Default: "localhost", Description: "name sent with EHLO"},
+		{Name: "banner", Type: "string", Description: "text the greeting of the server must contain"},
+		{Name: "capabilities", Type: "[]string", Description: `EHLO extensions the server must offer, e.g. ["SIZE", "8BITMIME"]`},
+	}
+	return append(params, mailParams("25", "465")...)
+}
+
+func (smtpChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := smtpConfig{mailConfig: defaultMailConfig(), HeloName: "localhost"}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+	if err := cfg.checkPasswordEnv(); err != nil {
+		return models.CheckResult{Status: "problem", Message: err.Error(), ErrorClass: ErrorClassConfig}
+	}
+
+	address := cfg.address(h, 25, 465)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	s, err := cfg.dial(ctx, h, address)
+	if err != nil {
+		return mailFailure(address, start, err)
+	}
+	defer s.close()
+
+	banner, ext, err := cfg.converse(ctx, s)
+	elapsed := time.Since(start)
+	if err != nil {
+		return mailFailure(address, start, err)
+	}
+
+	extensions := make([]string, 0, len(ext))
+	for name := range ext {
+		extensions = append(extensions, name)
+	}
+	sort.Strings(extensions)
+
+	r := models.CheckResult{
+		Status:   "healthy",
+		Message:  fmt.Sprintf("%s - %s", address, banner),
+		Duration: elapsed,
+		Details:  map[string]string{"banner": banner, "extensions": strings.Join(extensions, ", ")},
+		Metrics:  map[string]float64{"session_ms": float64(elapsed) / float64(time.Millisecond)},
+	}
+
+	if cfg.Banner != "" && !strings.Contains(banner, cfg.Banner) {
+		r.Status = "problem"
+		r.Message = fmt.Sprintf("%s - assertion failed: banner %q does not contain %q", address, banner, cfg.Banner)
+		r.ErrorClass = ErrorClassAssertion
+		return r
+	}
+	for _, want := range cfg.Capabilities {
+		if _, ok := ext[strings.ToUpper(want)]; !ok {
+			r.Status = "problem"
+			r.Message = fmt.Sprintf("%s - assertion failed: extension %s not offered", address, strings.ToUpper(want))
+			r.ErrorClass = ErrorClassAssertion
+			return r
+		}
+	}
+
+	cfg.apply(&r)
+	cfg.certificate(&r, s)
+	return r
+}
+
+// converse reads the greeting, says EHLO, upgrades with STARTTLS and logs in as
+// configured, returning the greeting and the EHLO extensions of the last EHLO
+func (cfg smtpConfig) converse(ctx context.Context, s *mailSession) (string, map[string]string, error) {
+	_, banner, err := s.text.ReadResponse(220)
+	if err != nil {
+		return "", nil, err
+	}
+
+	ext, err := cfg.ehlo(s)
+	if err != nil {
+		return banner, nil, err
+	}
+
+	_, offered := ext["STARTTLS"]
+	upgrade, err := s.wantTLS(cfg.StartTLS, offered)
+	if err != nil {
+		return banner, ext, err
+	}
+	if upgrade {
+		if _, _, err = s.cmd(220, "STARTTLS"); err != nil {
+			return banner, ext, err
+		}
+		if err = s.startTLS(ctx); err != nil {
+			return banner, ext, err
+		}
+		if ext, err = cfg.ehlo(s); err != nil {
+			return banner, nil, err
+		}
+	}
+
+	if cfg.User != "" {
+		if err = cfg.auth(s, ext["AUTH"]); err != nil {
+			return banner, ext, err
+		}
+	}
+
+	_, _, _ = s.cmd(221, "QUIT")
+	return banner, ext, nil
+}
+
+// ehlo greets the server and returns its extensions by their upper case name
+func (cfg smtpConfig) ehlo(s *mailSession) (map[string]string, error) {
+	_, msg, err := s.cmd(250, "EHLO %s", cfg.HeloName)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := make(map[string]string)
+	lines := strings.Split(msg, "\n")
+	for _, line := range lines[1:] {
+		name, args, _ := strings.Cut(line, " ")
+		ext[strings.ToUpper(name)] = args
+	}
+
+	return ext, nil
+}
+
+// auth logs in with AUTH PLAIN, or AUTH LOGIN when that is the only mechanism offered
+func (cfg smtpConfig) auth(s *mailSession, mechanisms string) error {
+	if err := s.canLogin(); err != nil {
+		return err
+	}
+
+	offered := make(map[string]bool)
+	for _, mechanism := range strings.Fields(strings.ToUpper(mechanisms)) {
+		offered[mechanism] = true
+	}
+
+	encode := base64.StdEncoding.EncodeToString
+	switch {
+	case offered["PLAIN"]:
+		_, _, err := s.cmd(235, "AUTH PLAIN %s", encode([]byte("\x00"+cfg.User+"\x00"+cfg.password())))
+		return err
+	case offered["LOGIN"]:
+		if _, _, err := s.cmd(334, "AUTH LOGIN"); err != nil {
+			return err
+		}
+		if _, _, err := s.cmd(334, "%s", encode([]byte(cfg.User))); err != nil {
+			return err
+		}
+		_, _, err := s.cmd(235, "%s", encode([]byte(cfg.password())))
+		return err
+	}
+
+	return fmt.Errorf("no supported AUTH mechanism offered (%s)", mechanisms)
+}
@@ -0,0 +1,335 @@
+package checks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+// fakeSMTP is a local stand-in smtp server speaking just enough of the protocol
+// for the check: greeting, EHLO, STARTTLS and AUTH PLAIN or LOGIN
+type fakeSMTP struct {
+	listener   net.Listener
+	banner     string
+	extensions []string
+	startTLS   bool
+	auth       string
+	user       string
+	password   string
+	tlsConfig  *tls.Config
+	auths      *atomic.Int32
+}
+
+func newFakeSMTP(t *testing.T, s *fakeSMTP) *fakeSMTP {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.listener = listener
+	s.auths = new(atomic.Int32)
+	if s.startTLS {
+		s.tlsConfig = &tls.Config{Certificates: []tls.Certificate{selfSignedCertificate(t)}}
+	}
+	go s.serve()
+	t.Cleanup(func() { _ = listener.Close() })
+
+	return s
+}
+
+func (s *fakeSMTP) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTP) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.session(conn)
+	}
+}
+
+func (s *fakeSMTP) session(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	text := textproto.NewConn(conn)
+	secure := false
+	_ = text.PrintfLine("220 %s", s.banner)
+
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			lines := []string{"fake.test greets " + arg}
+			lines = append(lines, s.extensions...)
+			if s.startTLS && !secure {
+				lines = append(lines, "STARTTLS")
+			}
+			if s.auth != "" && secure {
+				lines = append(lines, "AUTH "+s.auth)
+			}
+			for i, l := range lines {
+				sep := "-"
+				if i == len(lines)-1 {
+					sep = " "
+				}
+				_ = text.PrintfLine("250%s%s", sep, l)
+			}
+		case "STARTTLS":
+			if !s.startTLS || secure {
+				_ = text.PrintfLine("502 not implemented")
+				continue
+			}
+			_ = text.PrintfLine("220 ready to start tls")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err = tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			text = textproto.NewConn(tlsConn)
+			secure = true
+		case "AUTH":
+			s.auths.Add(1)
+			mechanism, initial, _ := strings.Cut(arg, " ")
+			var user, password string
+			switch strings.ToUpper(mechanism) {
+			case "PLAIN":
+				decoded, _ := base64.StdEncoding.DecodeString(initial)
+				parts := strings.Split(string(decoded), "\x00")
+				if len(parts) == 3 {
+					user, password = parts[1], parts[2]
+				}
+			case "LOGIN":
+				user, password = s.loginExchange(text)
+			}
+			if user == s.user && password == s.password {
+				_ = text.PrintfLine("235 authentication successful")
+			} else {
+				_ = text.PrintfLine("535 5.7.8 authentication credentials invalid")
+			}
+		case "QUIT":
+			_ = text.PrintfLine("221 bye")
+			return
+		default:
+			_ = text.PrintfLine("502 command not recognized")
+		}
+	}
+}
+
+// loginExchange prompts for the user and password of AUTH LOGIN
+func (s *fakeSMTP) loginExchange(text *textproto.Conn) (string, string) {
+	var answers []string
+	for _, prompt := range []string{"Username:", "Password:"} {
+		_ = text.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(prompt)))
+		line, err := text.ReadLine()
+		if err != nil {
+			return "", ""
+		}
+		decoded, _ := base64.StdEncoding.DecodeString(line)
+		answers = append(answers, string(decoded))
+	}
+	return answers[0], answers[1]
+}
+
+// selfSignedCertificate returns a certificate for localhost valid for a year
+func selfSignedCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestSMTPCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		server     fakeSMTP
+		config     models.ServiceConfig
+		wantStatus string
+		wantClass  string
+		wantTLS    bool
+		wantText   string
+		wantNoAuth bool
+	}{
+		{
+			name:       "plaintext greeting",
+			server:     fakeSMTP{banner: "mail.example.test ESMTP ready", extensions: []string{"SIZE 10240000", "8BITMIME"}},
+			config:     models.ServiceConfig{"banner": "ESMTP", "capabilities": []any{"size", "8BITMIME"}},
+			wantStatus: "healthy",
+			wantText:   "mail.example.test ESMTP ready",
+		},
+		{
+			name:       "banner not matching",
+			server:     fakeSMTP{banner: "mail.example.test ready"},
+			config:     models.ServiceConfig{"banner": "ESMTP"},
+			wantStatus: "problem",
+			wantClass:  ErrorClassAssertion,
+			wantText:   "banner",
+		},
+		{
+			name:       "extension not offered",
+			server:     fakeSMTP{banner: "ready", extensions: []string{"SIZE 10240000"}},
+			config:     models.ServiceConfig{"capabilities": []any{"PIPELINING"}},
+			wantStatus: "problem",
+			wantClass:  ErrorClassAssertion,
+			wantText:   "PIPELINING",
+		},
+		{
+			name:       "starttls upgrade",
+			server:     fakeSMTP{banner: "ready", startTLS: true},
+			config:     models.ServiceConfig{"starttls": "required", "insecure_skip_verify": true},
+			wantStatus: "healthy",
+			wantTLS:    true,
+		},
+		{
+			name:       "starttls upgrade with untrusted certificate",
+			server:     fakeSMTP{banner: "ready", startTLS: true},
+			config:     models.ServiceConfig{},
+			wantStatus: "problem",
+			wantClass:  ErrorClassTLS,
+			wantTLS:    true,
+			wantText:   "chain not trusted",
+		},
+		{
+			name:       "starttls required but not offered",
+			server:     fakeSMTP{banner: "ready"},
+			config:     models.ServiceConfig{"starttls": "required"},
+			wantStatus: "problem",
+			wantClass:  ErrorClassTLS,
+			wantText:   errNoStartTLS.Error(),
+		},
+		{
+			name:       "auth plain",
+			server:     fakeSMTP{banner: "ready", startTLS: true, auth: "PLAIN LOGIN", user: "monitor", password: "secret"},
+			config:     models.ServiceConfig{"user": "monitor", "password": "secret", "insecure_skip_verify": true},
+			wantStatus: "healthy",
+			wantTLS:    true,
+		},
+		{
+			name:       "auth login",
+			server:     fakeSMTP{banner: "ready", startTLS: true, auth: "LOGIN", user: "monitor", password: "secret"},
+			config:     models.ServiceConfig{"user": "monitor", "password": "secret", "insecure_skip_verify": true},
+			wantStatus: "healthy",
+			wantTLS:    true,
+		},
+		{
+			name:       "auth rejected",
+			server:     fakeSMTP{banner: "ready", startTLS: true, auth: "PLAIN", user: "monitor", password: "secret"},
+			config:     models.ServiceConfig{"user": "monitor", "password": "wrong", "insecure_skip_verify": true},
+			wantStatus: "problem",
+			wantClass:  ErrorClassProtocol,
+			wantText:   "535",
+		},
+		{
+			name:       "no auth mechanism in common",
+			server:     fakeSMTP{banner: "ready", startTLS: true, auth: "CRAM-MD5", user: "monitor", password: "secret"},
+			config:     models.ServiceConfig{"user": "monitor", "password": "secret", "insecure_skip_verify": true},
+			wantStatus: "problem",
+			wantClass:  ErrorClassUnknown,
+			wantText:   "no supported AUTH mechanism",
+		},
+		{
+			name:       "login refused without tls",
+			server:     fakeSMTP{banner: "ready", startTLS: true, auth: "PLAIN", user: "monitor", password: "secret"},
+			config:     models.ServiceConfig{"starttls": "none", "user": "monitor", "password": "secret"},
+			wantStatus: "problem",
+			wantClass:  ErrorClassConfig,
+			wantText:   errLoginWithoutTLS.Error(),
+		},
+		{
+			name:       "login refused with untrusted certificate",
+			server:     fakeSMTP{banner: "ready", startTLS: true, auth: "PLAIN LOGIN", user: "monitor", password: "secret"},
+			config:     models.ServiceConfig{"starttls": "required", "user": "monitor", "password": "secret"},
+			wantStatus: "problem",
+			wantClass:  ErrorClassTLS,
+			wantText:   errLoginUntrusted.Error(),
+			wantNoAuth: true,
+		},
+		{
+			name:       "invalid starttls mode",
+			server:     fakeSMTP{banner: "ready"},
+			config:     models.ServiceConfig{"starttls": "always"},
+			wantStatus: "problem",
+			wantText:   "invalid starttls",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTP(t, &tt.server)
+			tt.config["port"] = server.port()
+
+			r := smtpChecker{}.Check(context.Background(), models.Host{URL: "localhost", IP: "127.0.0.1"}, models.HostService{Config: tt.config})
+
+			if r.Status != tt.wantStatus || (tt.wantClass != "" && r.ErrorClass != tt.wantClass) {
+				t.Fatalf("got %s (%q): %s, expected %s (%q)", r.Status, r.ErrorClass, r.Message, tt.wantStatus, tt.wantClass)
+			}
+			if !strings.Contains(r.Message, tt.wantText) {
+				t.Errorf("message %q does not contain %q", r.Message, tt.wantText)
+			}
+			if tt.wantNoAuth && server.auths.Load() != 0 {
+				t.Errorf("credentials were sent in %d AUTH commands", server.auths.Load())
+			}
+			if tt.wantStatus == "healthy" || tt.wantTLS {
+				if upgraded := r.Details["tls"] != "none"; upgraded != tt.wantTLS {
+					t.Errorf("got tls %q, expected upgrade %v", r.Details["tls"], tt.wantTLS)
+				}
+			}
+		})
+	}
+}
+
+func TestSMTPCheckConnectionRefused(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	_ = listener.Close()
+
+	r := smtpChecker{}.Check(context.Background(), models.Host{IP: "127.0.0.1"}, models.HostService{Config: models.ServiceConfig{"port": port}})
+	if r.Status != "problem" || r.ErrorClass != ErrorClassConnection {
+		t.Fatalf("got %s (%q): %s, expected a connection problem", r.Status, r.ErrorClass, r.Message)
+	}
+	if !strings.Contains(r.Message, fmt.Sprintf("127.0.0.1:%d", port)) {
+		t.Errorf("message %q does not name the address", r.Message)
+	}
+}
@@ -0,0 +1,198 @@
+package checks
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func init() {
+	Register(sseChecker{})
+}
+
+// sseChecker opens a server-sent events stream and optionally waits for an expected event
+type sseChecker struct{}
+
+// sseConfig holds the parameters of a server-sent events check
+type sseConfig struct {
+	httpRequestSpec
+	latencyThresholds
+	messageExpectation
+	URL         string `json:"url"`
+	Path        string `json:"path"`
+	ExpectEvent string `json:"expect_event"`
+	Timeout     int    `json:"timeout"`
+}
+
+// sseEvent is an event read from a server-sent events stream
+type sseEvent struct {
+	name string
+	data string
+}
+
+func (sseChecker) Kind() string { return "sse" }
+
+func (sseChecker) Name() string { return "Server-Sent Events" }
+
+func (sseChecker) Icon() string { return "fas fa-stream" }
+
+func (sseChecker) Params() []Param {
+	params := []Param{
+		{Name: "url", Type: "string", Description: "url of the stream, defaults to the host url"},
+		{Name: "path", Type: "string", Description: "path requested instead of the one in the host url"},
+		{Name: "expect_event", Type: "string", Description: "event type to wait for"},
+		{Name: "expect", Type: "string", Description: "text the data of an event must contain; the check waits for it"},
+		{Name: "expect_regex", Type: "string", Description: "regular expression the data of an event must match; the check waits for it"},
+		{Name: "timeout", Type: "int", Default: "10", Description: "seconds to open the stream and receive the expected event"},
+	}
+	params = append(params, requestParams()...)
+	return append(params, latencyParams()...)
+}
+
+func (sseChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := sseConfig{Timeout: 10}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+
+	re, err := cfg.messageExpectation.compile()
+	if err != nil {
+		return models.CheckResult{Status: "problem", Message: err.Error(), ErrorClass: ErrorClassConfig}
+	}
+
+	target := cfg.URL
+	if target == "" {
+		scheme := "http"
+		if strings.HasPrefix(h.URL, "https://") {
+			scheme = "https"
+		}
+		target, err = httpChecker{scheme: scheme}.targetURL(h.URL, httpConfig{Path: cfg.Path})
+		if err != nil {
+			return models.CheckResult{Status: "problem", Message: fmt.Sprintf("%s - %s", h.URL, err), ErrorClass: ErrorClassConfig}
+		}
+	}
+
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// the stream stays open, so the context bounds the check instead of the client timeout
+	client, err := cfg.newClient(0, nil)
+	if err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("%s - %s", target, err), ErrorClass: ErrorClassConfig}
+	}
+
+	req, err := cfg.newRequest(ctx, target)
+	if err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("%s - %s", target, err), ErrorClass: ErrorClassConfig}
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	connect := time.Since(start)
+	if err != nil {
+		return models.CheckResult{
+			Status:     "problem",
+			Message:    fmt.Sprintf("%s - error connecting", target),
+			Duration:   connect,
+			ErrorClass: classifyError(err),
+			Details:    map[string]string{"error": err.Error()},
+		}
+	}
+	defer resp.Body.Close()
+
+	r := models.CheckResult{
+		Status:     "healthy",
+		Message:    fmt.Sprintf("%s - stream opened in %s", target, connect.Round(time.Millisecond)),
+		Duration:   connect,
+		StatusCode: resp.StatusCode,
+		Details:    map[string]string{},
+		Metrics:    map[string]float64{"connect_ms": float64(connect) / float64(time.Millisecond)},
+	}
+	if resp.TLS != nil {
+		r.TLSVersion = tls.VersionName(resp.TLS.Version)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if resp.StatusCode != http.StatusOK || mediaType != "text/event-stream" {
+		r.Status = "problem"
+		r.Message = fmt.Sprintf("%s - %s - assertion failed: expected a text/event-stream, got %q", target, resp.Status, resp.Header.Get("Content-Type"))
+		r.ErrorClass = ErrorClassAssertion
+		return r
+	}
+
+	if cfg.ExpectEvent == "" && cfg.Expect == "" && re == nil {
+		cfg.apply(&r)
+		return r
+	}
+
+	events := bufio.NewScanner(resp.Body)
+	for {
+		event, err := readSSEEvent(events)
+		if err != nil {
+			r.Status = "problem"
+			r.Message = fmt.Sprintf("%s - no expected event: %s", target, err)
+			r.ErrorClass = ErrorClassAssertion
+			return r
+		}
+
+		if (cfg.ExpectEvent == "" || event.name == cfg.ExpectEvent) && cfg.matches(event.data, re) {
+			wait := time.Since(start) - connect
+			r.Duration += wait
+			r.Metrics["first_event_ms"] = float64(wait) / float64(time.Millisecond)
+			r.Details["event"] = event.name
+			r.Details["data"] = event.data
+			r.Message = fmt.Sprintf("%s - expected event after %s", target, r.Duration.Round(time.Millisecond))
+			break
+		}
+	}
+
+	cfg.apply(&r)
+	return r
+}
+
+// readSSEEvent reads the next event of a stream; events without a type are of type message
+func readSSEEvent(lines *bufio.Scanner) (sseEvent, error) {
+	event := sseEvent{name: "message"}
+	var data []string
+	seen := false
+
+	for lines.Scan() {
+		line := lines.Text()
+		if line == "" {
+			if seen {
+				event.data = strings.Join(data, "\n")
+				return event, nil
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.name = value
+			seen = true
+		case "data":
+			data = append(data, value)
+			seen = true
+		}
+	}
+
+	if err := lines.Err(); err != nil {
+		return event, err
+	}
+	return event, fmt.Errorf("stream closed")
+}
@@ -0,0 +1,144 @@
+package checks
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func TestReadSSEEvent(t *testing.T) {
+	stream := ": keep alive\n\n" +
+		"data: first\n\n" +
+		"event: update\ndata: line one\ndata: line two\n\n" +
+		": a comment inside\nevent: ping\ndata:no space\n: trailing comment\n\n" +
+		"event: tick\n\n" +
+		"id: 7\nretry: 1000\ndata: {\"ok\":true}\n\n" +
+		"data: unterminated"
+
+	want := []sseEvent{
+		{name: "message", data: "first"},
+		{name: "update", data: "line one\nline two"},
+		{name: "ping", data: "no space"},
+		{name: "tick", data: ""},
+		{name: "message", data: `{"ok":true}`},
+	}
+
+	lines := bufio.NewScanner(strings.NewReader(stream))
+	for i, w := range want {
+		event, err := readSSEEvent(lines)
+		if err != nil {
+			t.Fatalf("event %d: %s", i, err)
+		}
+		if event != w {
+			t.Errorf("event %d: got %+v, expected %+v", i, event, w)
+		}
+	}
+
+	// an event not ended by a blank line is never dispatched
+	if event, err := readSSEEvent(lines); err == nil {
+		t.Errorf("got %+v, expected the stream to be closed", event)
+	}
+}
+
+func TestSSECheck(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprint(w, ": hello\n\nevent: status\ndata: starting\n\nevent: status\ndata: ready\ndata: 3 workers\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	mux.HandleFunc("/quiet", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, "<html></html>")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		config      models.ServiceConfig
+		wantStatus  string
+		wantClass   string
+		wantMessage string
+		wantData    string
+	}{
+		{
+			name:        "stream opened",
+			config:      models.ServiceConfig{"path": "/quiet"},
+			wantStatus:  "healthy",
+			wantMessage: "stream opened in",
+		},
+		{
+			name:        "not found",
+			config:      models.ServiceConfig{"path": "/missing"},
+			wantStatus:  "problem",
+			wantClass:   ErrorClassAssertion,
+			wantMessage: "404 Not Found",
+		},
+		{
+			name:        "not an event stream",
+			config:      models.ServiceConfig{"path": "/page"},
+			wantStatus:  "problem",
+			wantClass:   ErrorClassAssertion,
+			wantMessage: "expected a text/event-stream",
+		},
+		{
+			name:        "expected event",
+			config:      models.ServiceConfig{"path": "/events", "expect_event": "status", "expect": "ready"},
+			wantStatus:  "healthy",
+			wantMessage: "expected event after",
+			wantData:    "ready\n3 workers",
+		},
+		{
+			name:        "expected event by regex",
+			config:      models.ServiceConfig{"path": "/events", "expect_regex": `^\w+$`},
+			wantStatus:  "healthy",
+			wantMessage: "expected event after",
+			wantData:    "starting",
+		},
+		{
+			name:        "expected event never arrives",
+			config:      models.ServiceConfig{"path": "/events", "expect_event": "done", "timeout": 1},
+			wantStatus:  "problem",
+			wantClass:   ErrorClassAssertion,
+			wantMessage: "no expected event",
+		},
+		{
+			name:        "no event at all",
+			config:      models.ServiceConfig{"path": "/quiet", "expect": "anything", "timeout": 1},
+			wantStatus:  "problem",
+			wantClass:   ErrorClassAssertion,
+			wantMessage: "no expected event",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := sseChecker{}.Check(context.Background(), models.Host{URL: server.URL}, models.HostService{Config: tt.config})
+
+			if r.Status != tt.wantStatus || r.ErrorClass != tt.wantClass {
+				t.Fatalf("got %s (%q): %s, expected %s (%q)", r.Status, r.ErrorClass, r.Message, tt.wantStatus, tt.wantClass)
+			}
+			if !strings.Contains(r.Message, tt.wantMessage) {
+				t.Errorf("message %q does not contain %q", r.Message, tt.wantMessage)
+			}
+			if r.Details["data"] != tt.wantData {
+				t.Errorf("got data %q, expected %q", r.Details["data"], tt.wantData)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package checks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+	"golang.org/x/crypto/ssh"
+)
+
+func init() {
+	Register(sshChecker{})
+}
+
+// errHostKeyRead stops the ssh handshake once the host key is known, before authentication
+var errHostKeyRead = errors.New("host key read")
+
+// sshChecker reads the banner and host key of an ssh server and compares the
+// key to a pinned fingerprint
+type sshChecker struct{}
+
+// sshConfig holds the parameters of an ssh check
+type sshConfig struct {
+	latencyThresholds
+	Port              int      `json:"port"`
+	Fingerprints      []string `json:"fingerprints"`
+	HostKeyAlgorithms []string `json:"host_key_algorithms"`
+	Banner            string   `json:"banner"`
+	Timeout           int      `json:"timeout"`
+}
+
+// bannerConn records what is read from a connection until the ssh version line
+type bannerConn struct {
+	net.Conn
+	read bytes.Buffer
+	done bool
+}
+
+func (sshChecker) Kind() string { return "ssh" }
+
+func (sshChecker) Name() string { return "SSH" }
+
+func (sshChecker) Icon() string { return "fas fa-key" }
+
+func (sshChecker) Params() []Param {
+	params := []Param{
+		{Name: "port", Type: "int", Default: "22", Description: "port the ssh server listens on"},
+		{Name: "fingerprints", Type: "[]string", Description: `pinned host key fingerprints as printed by ssh-keygen -l, e.g. ["SHA256:nThbg6kXUpJWGl7E1IGOCspRomTxdCARLviKw6E5SY8"]; a key matching none is a problem`},
+		{Name: "host_key_algorithms", Type: "[]string", Description: `host key types asked for in order of preference, e.g. ["ssh-ed25519"]; set it to the type of the pinned key`},
+		{Name: "banner", Type: "string", Description: "text the version banner of the server must contain"},
+		{Name: "timeout", Type: "int", Default: "10", Description: "seconds for the connection and key exchange"},
+	}
+	return append(params, latencyParams()...)
+}
+
+func (sshChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := sshConfig{Port: 22, Timeout: 10}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+
+	address := net.JoinHostPort(hostAddress(h), strconv.Itoa(cfg.Port))
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return models.CheckResult{
+			Status:     "problem",
+			Message:    fmt.Sprintf("%s - %s", address, "error connecting"),
+			Duration:   time.Since(start),
+			ErrorClass: classifyError(err),
+			Details:    map[string]string{"error": err.Error()},
+		}
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	var hostKey ssh.PublicKey
+	clientConfig := &ssh.ClientConfig{
+		User:              "vigilate",
+		ClientVersion:     "SSH-2.0-vigilate",
+		HostKeyAlgorithms: cfg.HostKeyAlgorithms,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			hostKey = key
+			return errHostKeyRead
+		},
+	}
+
+	bc := &bannerConn{Conn: conn}
+	_, _, _, err = ssh.NewClientConn(bc, address, clientConfig)
+	elapsed := time.Since(start)
+	banner := bc.banner()
+
+	if hostKey == nil {
+		if err == nil {
+			err = errors.New("no host key presented")
+		}
+		return models.CheckResult{
+			Status:     "problem",
+			Message:    fmt.Sprintf("%s - %s", address, err),
+			Duration:   elapsed,
+			ErrorClass: classifySSHError(err),
+			Details:    map[string]string{"banner": banner},
+		}
+	}
+
+	fingerprint := ssh.FingerprintSHA256(hostKey)
+	r := models.CheckResult{
+		Status:   "healthy",
+		Message:  fmt.Sprintf("%s - %s %s", address, hostKey.Type(), fingerprint),
+		Duration: elapsed,
+		Details: map[string]string{
+			"banner":          banner,
+			"host_key_type":   hostKey.Type(),
+			"fingerprint":     fingerprint,
+			"fingerprint_md5": ssh.FingerprintLegacyMD5(hostKey),
+		},
+		Metrics: map[string]float64{"handshake_ms": float64(elapsed) / float64(time.Millisecond)},
+	}
+
+	if cfg.Banner != "" && !strings.Contains(banner, cfg.Banner) {
+		r.Status = "problem"
+		r.Message = fmt.Sprintf("%s - assertion failed: banner %q does not contain %q", address, banner, cfg.Banner)
+		r.ErrorClass = ErrorClassAssertion
+		return r
+	}
+
+	if len(cfg.Fingerprints) > 0 && !fingerprintPinned(hostKey, cfg.Fingerprints) {
+		r.Status = "problem"
+		r.Message = fmt.Sprintf("%s - host key changed: %s %s matches no pinned fingerprint", address, hostKey.Type(), fingerprint)
+		r.ErrorClass = ErrorClassAssertion
+		return r
+	}
+
+	cfg.apply(&r)
+	return r
+}
+
+func (c *bannerConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if !c.done && c.read.Len() < 1024 {
+		c.read.Write(p[:n])
+		c.done = c.banner() != ""
+	}
+	return n, err
+}
+
+// banner returns the version line of the server once it has been read in full
+func (c *bannerConn) banner() string {
+	lines := strings.Split(c.read.String(), "\n")
+	for _, line := range lines[:len(lines)-1] {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "SSH-") {
+			return line
+		}
+	}
+	return ""
+}
+
+// fingerprintPinned reports whether a key matches one of the pinned fingerprints,
+// written as SHA256:... or as the legacy colon separated md5
+func fingerprintPinned(key ssh.PublicKey, pinned []string) bool {
+	sha := ssh.FingerprintSHA256(key)
+	md5 := ssh.FingerprintLegacyMD5(key)
+
+	for _, p := range pinned {
+		p = strings.TrimSpace(p)
+		if p == sha || strings.EqualFold(strings.TrimPrefix(p, "MD5:"), md5) {
+			return true
+		}
+	}
+	return false
+}
+
+// classifySSHError maps a failed handshake onto an error class
+func classifySSHError(err error) string {
+	if class := classifyError(err); class != ErrorClassUnknown {
+		return class
+	}
+	return ErrorClassProtocol
+}
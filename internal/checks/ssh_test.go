@@ -0,0 +1,145 @@
+package checks
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/namhuydao/vigilate/internal/models"
+	"golang.org/x/crypto/ssh"
+)
+
+// sshFixture is a local stand-in ssh server presenting key, returning its port
+func sshFixture(t *testing.T, key ssh.Signer) int {
+	t.Helper()
+
+	config := &ssh.ServerConfig{NoClientAuth: true, ServerVersion: "SSH-2.0-OpenSSH_9.6 fixture"}
+	config.AddHostKey(key)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				// the check hangs up once it has the host key, so the handshake always fails here
+				_, _, _, _ = ssh.NewServerConn(conn, config)
+			}(conn)
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// ed25519Signer returns a newly generated ed25519 host key
+func ed25519Signer(t *testing.T) ssh.Signer {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func TestSSHCheck(t *testing.T) {
+	key := ed25519Signer(t)
+	other := ed25519Signer(t)
+	port := sshFixture(t, key)
+
+	sha := ssh.FingerprintSHA256(key.PublicKey())
+	md5 := ssh.FingerprintLegacyMD5(key.PublicKey())
+
+	tests := []struct {
+		name       string
+		config     models.ServiceConfig
+		wantStatus string
+		wantClass  string
+		wantText   string
+	}{
+		{
+			name:       "not pinned",
+			config:     models.ServiceConfig{},
+			wantStatus: "healthy",
+			wantText:   sha,
+		},
+		{
+			name:       "matching sha256",
+			config:     models.ServiceConfig{"fingerprints": []any{ssh.FingerprintSHA256(other.PublicKey()), sha}},
+			wantStatus: "healthy",
+			wantText:   sha,
+		},
+		{
+			name:       "matching legacy md5",
+			config:     models.ServiceConfig{"fingerprints": []any{"MD5:" + strings.ToUpper(md5)}},
+			wantStatus: "healthy",
+		},
+		{
+			name:       "matching legacy md5 without prefix",
+			config:     models.ServiceConfig{"fingerprints": []any{" " + md5 + " "}},
+			wantStatus: "healthy",
+		},
+		{
+			name:       "host key changed",
+			config:     models.ServiceConfig{"fingerprints": []any{ssh.FingerprintSHA256(other.PublicKey()), "MD5:" + ssh.FingerprintLegacyMD5(other.PublicKey())}},
+			wantStatus: "problem",
+			wantClass:  ErrorClassAssertion,
+			wantText:   "host key changed",
+		},
+		{
+			name:       "banner",
+			config:     models.ServiceConfig{"banner": "OpenSSH_9"},
+			wantStatus: "healthy",
+		},
+		{
+			name:       "banner not matching",
+			config:     models.ServiceConfig{"banner": "dropbear"},
+			wantStatus: "problem",
+			wantClass:  ErrorClassAssertion,
+			wantText:   "banner",
+		},
+		{
+			name:       "no host key of the asked type",
+			config:     models.ServiceConfig{"host_key_algorithms": []any{"rsa-sha2-256"}},
+			wantStatus: "problem",
+			wantClass:  ErrorClassProtocol,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config["port"] = port
+			tt.config["timeout"] = 5
+
+			r := sshChecker{}.Check(context.Background(), models.Host{IP: "127.0.0.1"}, models.HostService{Config: tt.config})
+
+			if r.Status != tt.wantStatus || r.ErrorClass != tt.wantClass {
+				t.Fatalf("got %s (%q): %s, expected %s (%q)", r.Status, r.ErrorClass, r.Message, tt.wantStatus, tt.wantClass)
+			}
+			if !strings.Contains(r.Message, tt.wantText) {
+				t.Errorf("message %q does not contain %q", r.Message, tt.wantText)
+			}
+			if r.Details["banner"] != "SSH-2.0-OpenSSH_9.6 fixture" {
+				t.Errorf("got banner %q", r.Details["banner"])
+			}
+			if tt.wantClass != ErrorClassProtocol && (r.Details["fingerprint"] != sha || r.Details["fingerprint_md5"] != md5) {
+				t.Errorf("got fingerprints %q and %q, expected %q and %q", r.Details["fingerprint"], r.Details["fingerprint_md5"], sha, md5)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package checks
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/certificateutils"
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func init() {
+	Register(sslChecker{})
+}
+
+// sslChecker checks the certificate served by the host
+type sslChecker struct{}
+
+// sslConfig holds the parameters of an ssl certificate check
+type sslConfig struct {
+	expiryThresholds
+	Port          int               `json:"port"`
+	ServerName    string            `json:"server_name"`
+	CAFile        string            `json:"ca_file"`
+	MinTLSVersion string            `json:"min_tls_version"`
+	MinRSABits    int               `json:"min_rsa_bits"`
+	MinECDSABits  int               `json:"min_ecdsa_bits"`
+	Severity      map[string]string `json:"severity"`
+	CheckOCSP     *bool             `json:"check_ocsp"`
+	OCSPServer    string            `json:"ocsp_server"`
+	CheckCRL      bool              `json:"check_crl"`
+	CRLURL        string            `json:"crl_url"`
+}
+
+// default status of each certificate validation failure, overridable with the severity parameter
+var sslFailureSeverity = map[string]string{
+	"untrusted_chain":    "problem",
+	"hostname_mismatch":  "problem",
+	"self_signed":        "problem",
+	"weak_key":           "warning",
+	"weak_signature":     "warning",
+	"old_tls_version":    "warning",
+	"revoked":            "problem",
+	"unknown_revocation": "warning",
+}
+
+// signature algorithms no longer considered safe
+var weakSignatureAlgorithms = map[x509.SignatureAlgorithm]bool{
+	x509.MD2WithRSA:    true,
+	x509.MD5WithRSA:    true,
+	x509.SHA1WithRSA:   true,
+	x509.DSAWithSHA1:   true,
+	x509.ECDSAWithSHA1: true,
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+func (sslChecker) Kind() string { return "ssl" }
+
+func (sslChecker) Name() string { return "SSL Certificate" }
+
+func (sslChecker) Icon() string { return "fas fa-lock" }
+
+func (sslChecker) Params() []Param {
+	params := []Param{
+		{Name: "port", Type: "int", Default: "443", Description: "port the certificate is served on"},
+		{Name: "server_name", Type: "string", Description: "name sent with SNI and matched against the certificate, defaults to the url host"},
+		{Name: "ca_file", Type: "string", Description: "PEM file of CA certificates trusted instead of the system roots"},
+		{Name: "min_tls_version", Type: "string", Default: "1.2", Description: "lowest acceptable negotiated tls version"},
+		{Name: "min_rsa_bits", Type: "int", Default: "2048", Description: "smallest acceptable rsa key"},
+		{Name: "min_ecdsa_bits", Type: "int", Default: "256", Description: "smallest acceptable ecdsa key"},
+		{Name: "check_ocsp", Type: "bool", Default: "true", Description: "ask the ocsp responder when the server staples no response"},
+		{Name: "ocsp_server", Type: "string", Description: "ocsp responder used instead of the one in the certificate"},
+		{Name: "check_crl", Type: "bool", Default: "false", Description: "download the certificate revocation lists when ocsp gives no answer"},
+		{Name: "crl_url", Type: "string", Description: "crl used instead of the distribution points in the certificate"},
+		{Name: "severity", Type: "map", Description: `status for each failure, e.g. {"self_signed": "warning"}; failures are untrusted_chain, hostname_mismatch, self_signed, weak_key, weak_signature, old_tls_version, revoked, unknown_revocation`},
+	}
+	return append(params, expiryParams()...)
+}
+
+func (sslChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := sslConfig{
+		expiryThresholds: defaultExpiryThresholds(),
+		MinTLSVersion:    "1.2",
+		MinRSABits:       2048,
+		MinECDSABits:     256,
+	}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+
+	address := sslAddress(h, cfg.Port)
+
+	opts := certificateutils.VerifyOptions{
+		ServerName: cfg.ServerName,
+		CheckOCSP:  cfg.CheckOCSP == nil || *cfg.CheckOCSP,
+		OCSPServer: cfg.OCSPServer,
+		CheckCRL:   cfg.CheckCRL,
+		CRLURL:     cfg.CRLURL,
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return models.CheckResult{Status: "problem", Message: err.Error(), ErrorClass: ErrorClassConfig}
+		}
+		opts.RootCAs = x509.NewCertPool()
+		if !opts.RootCAs.AppendCertsFromPEM(pem) {
+			return models.CheckResult{Status: "problem", Message: fmt.Sprintf("no certificates found in %s", cfg.CAFile), ErrorClass: ErrorClassConfig}
+		}
+	}
+
+	var r models.CheckResult
+
+	certDetails, err := certificateutils.GetCertificateDetailsWithOptions(ctx, address, 10, opts)
+	if err != nil {
+		r.Status = "problem"
+		r.Message = fmt.Sprintf("%s - %s", address, err)
+		r.ErrorClass = classifyError(err)
+		r.Details = map[string]string{"error": err.Error()}
+		return r
+	}
+
+	r.Duration = certDetails.TimeTaken
+	r.TLSVersion = certDetails.TLSVersion
+	r.Details = map[string]string{
+		"subject":             certDetails.SubjectName,
+		"issuer":              certDetails.IssuerName,
+		"serial_number":       certDetails.SerialNumber,
+		"expiration_date":     certDetails.ExpirationDate,
+		"sans":                strings.Join(certDetails.SANs, ", "),
+		"chain":               strings.Join(certDetails.Chain, " > "),
+		"key":                 fmt.Sprintf("%s %d", certDetails.KeyType, certDetails.KeySize),
+		"signature_algorithm": certDetails.SignatureAlgorithm,
+	}
+	if certDetails.RevocationStatus != "" {
+		r.Details["revocation"] = certDetails.RevocationStatus
+		if certDetails.RevocationSource != "" {
+			r.Details["revocation"] = fmt.Sprintf("%s (%s)", certDetails.RevocationStatus, certDetails.RevocationSource)
+		}
+		if certDetails.RevocationError != "" {
+			r.Details["revocation_error"] = certDetails.RevocationError
+		}
+	}
+	r.Metrics = map[string]float64{
+		"days_until_expiration": float64(certDetails.DaysUntilExpiration),
+		"key_size":              float64(certDetails.KeySize),
+	}
+
+	r.Status = cfg.expiryThresholds.status(certDetails)
+	if certDetails.Expired {
+		r.Message = certDetails.Hostname + " has expired!"
+	} else {
+		r.Message = certDetails.Hostname + " expiring in " + strconv.Itoa(certDetails.DaysUntilExpiration) + " days"
+	}
+
+	failures := cfg.validate(certDetails)
+	for _, f := range failures {
+		severity := sslFailureSeverity[f.key]
+		if s, ok := cfg.Severity[f.key]; ok {
+			severity = s
+		}
+		r.Status = worseStatus(r.Status, severity)
+		r.Message = fmt.Sprintf("%s; %s", r.Message, f.message)
+	}
+	if len(failures) > 0 {
+		r.ErrorClass = ErrorClassTLS
+	}
+
+	return r
+}
+
+// sslFailure is a certificate validation failure
+type sslFailure struct {
+	key     string
+	message string
+}
+
+// validate returns every validation failure of a certificate
+func (cfg sslConfig) validate(cd certificateutils.CertificateDetails) []sslFailure {
+	var failures []sslFailure
+
+	if cd.SelfSigned {
+		failures = append(failures, sslFailure{"self_signed", "certificate is self-signed"})
+	} else if !cd.ChainVerified {
+		failures = append(failures, sslFailure{"untrusted_chain", "chain not trusted: " + cd.ChainError})
+	}
+
+	if !cd.HostnameVerified {
+		failures = append(failures, sslFailure{"hostname_mismatch", cd.HostnameError})
+	}
+
+	if (cd.KeyType == "RSA" && cd.KeySize < cfg.MinRSABits) || (cd.KeyType == "ECDSA" && cd.KeySize < cfg.MinECDSABits) {
+		failures = append(failures, sslFailure{"weak_key", fmt.Sprintf("weak %s key of %d bits", cd.KeyType, cd.KeySize)})
+	}
+
+	for alg := range weakSignatureAlgorithms {
+		if alg.String() == cd.SignatureAlgorithm {
+			failures = append(failures, sslFailure{"weak_signature", "weak signature algorithm " + cd.SignatureAlgorithm})
+			break
+		}
+	}
+
+	if minVersion, ok := tlsVersions[cfg.MinTLSVersion]; ok {
+		for _, version := range tlsVersions {
+			if tls.VersionName(version) == cd.TLSVersion && version < minVersion {
+				failures = append(failures, sslFailure{"old_tls_version", fmt.Sprintf("negotiated %s, below %s", cd.TLSVersion, tls.VersionName(minVersion))})
+			}
+		}
+	}
+
+	switch cd.RevocationStatus {
+	case certificateutils.RevocationRevoked:
+		failures = append(failures, sslFailure{"revoked", fmt.Sprintf("certificate revoked on %s", cd.RevokedAt.Format(time.UnixDate))})
+	case certificateutils.RevocationUnknown:
+		failures = append(failures, sslFailure{"unknown_revocation", "revocation status unknown: " + cd.RevocationError})
+	}
+
+	return failures
+}
+
+// sslAddress returns the host:port the certificate of a host is fetched from
+func sslAddress(h models.Host, port int) string {
+	raw := h.URL
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	host := hostName(h)
+	if u, err := url.Parse(raw); err == nil && u.Port() != "" && port == 0 {
+		port, _ = strconv.Atoi(u.Port())
+	}
+	if port == 0 {
+		port = 443
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
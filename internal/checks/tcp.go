@@ -0,0 +1,81 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func init() {
+	Register(tcpChecker{})
+}
+
+// tcpChecker opens a tcp connection to a port on the host
+type tcpChecker struct{}
+
+// tcpConfig holds the parameters of a tcp check
+type tcpConfig struct {
+	latencyThresholds
+	Port    int `json:"port"`
+	Timeout int `json:"timeout"`
+}
+
+func (tcpChecker) Kind() string { return "tcp" }
+
+func (tcpChecker) Name() string { return "TCP" }
+
+func (tcpChecker) Icon() string { return "fas fa-network-wired" }
+
+func (tcpChecker) Params() []Param {
+	params := []Param{
+		{Name: "port", Type: "int", Description: "port to connect to (required)"},
+		{Name: "timeout", Type: "int", Default: "5", Description: "seconds to wait for the connection"},
+	}
+	return append(params, latencyParams()...)
+}
+
+func (tcpChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := tcpConfig{Timeout: 5}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+
+	if cfg.Port <= 0 || cfg.Port > 65535 {
+		return models.CheckResult{Status: "problem", Message: "no valid port configured", ErrorClass: ErrorClassConfig}
+	}
+
+	address := net.JoinHostPort(hostAddress(h), strconv.Itoa(cfg.Port))
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+
+	var d net.Dialer
+	start := time.Now()
+	conn, err := d.DialContext(ctx, "tcp", address)
+	elapsed := time.Since(start)
+	if err != nil {
+		return models.CheckResult{
+			Status:     "problem",
+			Message:    fmt.Sprintf("%s - %s", address, "error connecting"),
+			Duration:   elapsed,
+			ErrorClass: classifyError(err),
+			Details:    map[string]string{"error": err.Error()},
+		}
+	}
+	_ = conn.Close()
+
+	r := models.CheckResult{
+		Status:   "healthy",
+		Message:  fmt.Sprintf("%s - connected in %s", address, elapsed.Round(time.Millisecond)),
+		Duration: elapsed,
+		Details:  map[string]string{"remote_addr": conn.RemoteAddr().String()},
+		Metrics:  map[string]float64{"connect_ms": float64(elapsed) / float64(time.Millisecond)},
+	}
+
+	cfg.apply(&r)
+	return r
+}
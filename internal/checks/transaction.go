@@ -0,0 +1,308 @@
+package checks
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func init() {
+	Register(transactionChecker{})
+}
+
+// transactionChecker runs an ordered list of http requests sharing cookies and
+// variables, such as logging in and loading a page behind the login
+type transactionChecker struct{}
+
+// transactionConfig holds the parameters of a transaction check
+type transactionConfig struct {
+	latencyThresholds
+	Steps     []transactionStep `json:"steps"`
+	Variables map[string]string `json:"variables"`
+	Timeout   int               `json:"timeout"`
+}
+
+// transactionStep is one request of a transaction
+type transactionStep struct {
+	httpRequestSpec
+	httpAssertions
+	Name         string        `json:"name"`
+	URL          string        `json:"url"`
+	Extract      []extractRule `json:"extract"`
+	MaxBodyBytes int64         `json:"max_body_bytes"`
+}
+
+// extractRule stores a value of a response in a variable for later steps
+type extractRule struct {
+	Name   string `json:"name"`
+	Regex  string `json:"regex"`
+	JSON   string `json:"json"`
+	Header string `json:"header"`
+}
+
+// variablePattern matches a {{name}} reference to a transaction variable
+var variablePattern = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+func (transactionChecker) Kind() string { return "transaction" }
+
+func (transactionChecker) Name() string { return "Transaction" }
+
+func (transactionChecker) Icon() string { return "fas fa-route" }
+
+func (transactionChecker) Params() []Param {
+	params := []Param{
+		{Name: "steps", Type: "[]object", Description: `requests run in order, each with a name, a url (absolute or relative to the host url), ` +
+			`the request parameters and assertions of an http check, and extract rules storing the first group of a regex, ` +
+			`a json path or a response header as a variable for later steps, e.g. ` +
+			`[{"name": "login", "url": "/login", "method": "POST", "body": "user=u&csrf={{csrf}}", "extract": [{"name": "token", "json": "data.token"}]}]`},
+		{Name: "variables", Type: "map", Description: "initial variables, referenced as {{name}} in urls, headers, bodies and credentials"},
+		{Name: "timeout", Type: "int", Default: "30", Description: "seconds for the whole transaction"},
+	}
+	return append(params, latencyParams()...)
+}
+
+func (transactionChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := transactionConfig{Timeout: 30}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+
+	if len(cfg.Steps) == 0 {
+		return models.CheckResult{Status: "problem", Message: "no steps configured", ErrorClass: ErrorClassConfig}
+	}
+
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	jar, _ := cookiejar.New(nil)
+	vars := make(map[string]string, len(cfg.Variables))
+	for k, v := range cfg.Variables {
+		vars[k] = v
+	}
+
+	r := models.CheckResult{
+		Status:  "healthy",
+		Details: map[string]string{},
+		Metrics: map[string]float64{},
+	}
+	var summary []string
+
+	for i, step := range cfg.Steps {
+		label := fmt.Sprintf("step %d", i+1)
+		if step.Name != "" {
+			label = fmt.Sprintf("step %d (%s)", i+1, step.Name)
+		}
+
+		resp, elapsed, err := step.run(ctx, h, vars, timeout, jar)
+		r.Duration += elapsed
+		r.Metrics[fmt.Sprintf("step_%d_ms", i+1)] = float64(elapsed) / float64(time.Millisecond)
+
+		if resp != nil {
+			r.StatusCode = resp.StatusCode
+			if resp.TLS != nil {
+				r.TLSVersion = tls.VersionName(resp.TLS.Version)
+			}
+			summary = append(summary, fmt.Sprintf("%s: %s in %s", label, resp.Status, elapsed.Round(time.Millisecond)))
+		}
+
+		if err != nil {
+			r.Status = "problem"
+			r.Message = fmt.Sprintf("%s - %s", label, err.message)
+			r.ErrorClass = err.class
+			r.Details["failed_step"] = strconv.Itoa(i + 1)
+			r.Details["failed_url"] = step.URL
+			r.Details["steps"] = strings.Join(summary, "\n")
+			if err.assertion != "" {
+				r.Details["assertion"] = err.assertion
+			}
+			if err.cause != nil {
+				r.Details["error"] = err.cause.Error()
+			}
+			return r
+		}
+	}
+
+	r.Message = fmt.Sprintf("%d steps passed in %s", len(cfg.Steps), r.Duration.Round(time.Millisecond))
+	r.Details["steps"] = strings.Join(summary, "\n")
+
+	cfg.apply(&r)
+	return r
+}
+
+// stepError describes why a step failed and its error class; the message leaves
+// out the expanded url, which may carry the values of variables
+type stepError struct {
+	message   string
+	class     string
+	cause     error
+	assertion string
+}
+
+// withoutURL drops the url an http client error is wrapped with
+func withoutURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// run sends the request of a step, checks the response and stores its extracted
+// variables; the response is returned whenever one was received
+func (step transactionStep) run(ctx context.Context, h models.Host, vars map[string]string, timeout time.Duration, jar http.CookieJar) (*http.Response, time.Duration, *stepError) {
+	spec, target, err := step.expand(h, vars)
+	if err != nil {
+		return nil, 0, &stepError{message: err.Error(), class: ErrorClassConfig}
+	}
+
+	client, err := spec.newClient(timeout, jar)
+	if err != nil {
+		return nil, 0, &stepError{message: err.Error(), class: ErrorClassConfig}
+	}
+
+	req, err := spec.newRequest(ctx, target)
+	if err != nil {
+		return nil, 0, &stepError{message: "invalid request", class: ErrorClassConfig, cause: withoutURL(err)}
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, time.Since(start), &stepError{message: "error connecting", class: classifyError(err), cause: withoutURL(err)}
+	}
+	defer resp.Body.Close()
+
+	maxBody := step.MaxBodyBytes
+	if maxBody <= 0 {
+		maxBody = defaultMaxBodyBytes
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	elapsed := time.Since(start)
+	if err != nil {
+		return resp, elapsed, &stepError{message: "error reading body", class: classifyError(err), cause: err}
+	}
+
+	assertions := step.httpAssertions
+	if len(assertions.ExpectedStatus) == 0 {
+		assertions.ExpectedStatus = []statusRange{{min: http.StatusOK, max: http.StatusOK}}
+	}
+	if err = assertions.check(resp, body); err != nil {
+		return resp, elapsed, &stepError{
+			message:   fmt.Sprintf("%s - %s assertion failed", resp.Status, assertionName(err)),
+			class:     ErrorClassAssertion,
+			assertion: err.Error(),
+		}
+	}
+
+	for _, rule := range step.Extract {
+		value, err := rule.extract(resp, body)
+		if err != nil {
+			return resp, elapsed, &stepError{message: fmt.Sprintf("extracting %s: %s", rule.Name, err), class: ErrorClassAssertion}
+		}
+		vars[rule.Name] = value
+	}
+
+	return resp, elapsed, nil
+}
+
+// expand returns the request of a step with its variables replaced, and the
+// url it is sent to resolved against the host url
+func (step transactionStep) expand(h models.Host, vars map[string]string) (httpRequestSpec, string, error) {
+	var missing []string
+	replace := func(s string) string {
+		return variablePattern.ReplaceAllStringFunc(s, func(ref string) string {
+			name := variablePattern.FindStringSubmatch(ref)[1]
+			value, ok := vars[name]
+			if !ok {
+				missing = append(missing, name)
+			}
+			return value
+		})
+	}
+
+	spec := step.httpRequestSpec
+	spec.Body = replace(spec.Body)
+	spec.BearerToken = replace(spec.BearerToken)
+	if spec.BasicAuth != nil {
+		spec.BasicAuth = &basicAuth{Username: replace(spec.BasicAuth.Username), Password: replace(spec.BasicAuth.Password)}
+	}
+	headers := make(map[string]string, len(spec.Headers))
+	for k, v := range spec.Headers {
+		headers[k] = replace(v)
+	}
+	spec.Headers = headers
+	rawURL := replace(step.URL)
+
+	if len(missing) > 0 {
+		return spec, "", fmt.Errorf("undefined variable %s", strings.Join(missing, ", "))
+	}
+
+	base := h.URL
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return spec, "", err
+	}
+	ref, err := url.Parse(rawURL)
+	if err != nil {
+		return spec, "", err
+	}
+
+	return spec, baseURL.ResolveReference(ref).String(), nil
+}
+
+// extract reads the value of a rule from a response
+func (rule extractRule) extract(resp *http.Response, body []byte) (string, error) {
+	switch {
+	case rule.Regex != "":
+		re, err := regexp.Compile(rule.Regex)
+		if err != nil {
+			return "", fmt.Errorf("invalid regex: %s", err)
+		}
+		match := re.FindSubmatch(body)
+		if match == nil {
+			return "", fmt.Errorf("body does not match %q", rule.Regex)
+		}
+		if len(match) > 1 {
+			return string(match[1]), nil
+		}
+		return string(match[0]), nil
+	case rule.JSON != "":
+		var doc any
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return "", fmt.Errorf("body is not valid json: %s", err)
+		}
+		v, found := lookupJSONPath(doc, rule.JSON)
+		if !found {
+			return "", fmt.Errorf("json %s missing", rule.JSON)
+		}
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		b, err := json.Marshal(v)
+		return string(b), err
+	case rule.Header != "":
+		value := resp.Header.Get(rule.Header)
+		if value == "" {
+			return "", fmt.Errorf("header %s missing", rule.Header)
+		}
+		return value, nil
+	}
+
+	return "", fmt.Errorf("no regex, json or header to extract from")
+}
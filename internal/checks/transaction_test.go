@@ -0,0 +1,110 @@
+package checks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func TestTransactionCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"data": {"token": "s3cr3t-token"}}`))
+		case "/account":
+			if r.URL.Query().Get("token") != "s3cr3t-token" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte("welcome back"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	login := map[string]any{"name": "login", "url": "/login", "extract": []any{map[string]any{"name": "token", "json": "data.token"}}}
+
+	tests := []struct {
+		name        string
+		steps       []any
+		wantStatus  string
+		wantClass   string
+		wantMessage string
+		wantURL     string
+	}{
+		{
+			name:        "steps pass",
+			steps:       []any{login, map[string]any{"name": "account", "url": "/account?token={{token}}", "body_contains": []any{"welcome"}}},
+			wantStatus:  "healthy",
+			wantMessage: "2 steps passed",
+		},
+		{
+			name:        "assertion failed",
+			steps:       []any{login, map[string]any{"name": "account", "url": "/account?token={{token}}", "body_contains": []any{"goodbye"}}},
+			wantStatus:  "problem",
+			wantClass:   ErrorClassAssertion,
+			wantMessage: "step 2 (account) - 200 OK - body_contains assertion failed",
+			wantURL:     "/account?token={{token}}",
+		},
+		{
+			name:        "unexpected status",
+			steps:       []any{map[string]any{"url": "/account?token=wrong"}},
+			wantStatus:  "problem",
+			wantClass:   ErrorClassAssertion,
+			wantMessage: "step 1 - 403 Forbidden - expected_status assertion failed",
+			wantURL:     "/account?token=wrong",
+		},
+		{
+			name:        "extract failed",
+			steps:       []any{map[string]any{"url": "/login", "extract": []any{map[string]any{"name": "id", "header": "X-Id"}}}},
+			wantStatus:  "problem",
+			wantClass:   ErrorClassAssertion,
+			wantMessage: "step 1 - extracting id: header X-Id missing",
+			wantURL:     "/login",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs := models.HostService{Config: models.ServiceConfig{"steps": tt.steps}}
+			r := transactionChecker{}.Check(context.Background(), models.Host{URL: server.URL}, hs)
+
+			if r.Status != tt.wantStatus || r.ErrorClass != tt.wantClass {
+				t.Fatalf("got %s (%q): %s, expected %s (%q)", r.Status, r.ErrorClass, r.Message, tt.wantStatus, tt.wantClass)
+			}
+			if !strings.HasPrefix(r.Message, tt.wantMessage) {
+				t.Errorf("got message %q, expected %q", r.Message, tt.wantMessage)
+			}
+			if strings.Contains(r.Message, "s3cr3t-token") || strings.Contains(r.Message, server.URL) {
+				t.Errorf("message %q leaks the expanded url", r.Message)
+			}
+			if r.Details["failed_url"] != tt.wantURL {
+				t.Errorf("got failed url %q, expected %q", r.Details["failed_url"], tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestTransactionCheckConnectionError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	hs := models.HostService{Config: models.ServiceConfig{
+		"variables": map[string]any{"token": "s3cr3t-token"},
+		"steps":     []any{map[string]any{"url": "/account?token={{token}}"}},
+	}}
+	r := transactionChecker{}.Check(context.Background(), models.Host{URL: server.URL}, hs)
+
+	if r.Status != "problem" || r.Message != "step 1 - error connecting" {
+		t.Fatalf("got %s: %s, expected a connection problem", r.Status, r.Message)
+	}
+	if strings.Contains(r.Details["error"], "s3cr3t-token") {
+		t.Errorf("error %q leaks the expanded url", r.Details["error"])
+	}
+}
@@ -0,0 +1,317 @@
+package checks
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func init() {
+	Register(udpChecker{})
+}
+
+// default clock offset thresholds in milliseconds of the ntp mode
+const (
+	defaultNTPWarningOffsetMs = 100
+	defaultNTPProblemOffsetMs = 1000
+)
+
+// ntpEpochOffset is the seconds between the ntp epoch, 1900, and the unix epoch
+const ntpEpochOffset = 2208988800
+
+// maxDatagram is the largest udp payload read
+const maxDatagram = 65535
+
+// udpChecker sends a datagram to a port on the host and optionally waits for a response
+type udpChecker struct{}
+
+// udpConfig holds the parameters of a udp check
+type udpConfig struct {
+	latencyThresholds
+	messageExpectation
+	Port            int    `json:"port"`
+	Mode            string `json:"mode"`
+	Payload         string `json:"payload"`
+	PayloadHex      string `json:"payload_hex"`
+	ExpectResponse  bool   `json:"expect_response"`
+	WarningOffsetMs int    `json:"warning_offset_ms"`
+	ProblemOffsetMs int    `json:"problem_offset_ms"`
+	Timeout         int    `json:"timeout"`
+}
+
+func (udpChecker) Kind() string { return "udp" }
+
+func (udpChecker) Name() string { return "UDP" }
+
+func (udpChecker) Icon() string { return "fas fa-exchange-alt" }
+
+func (udpChecker) Params() []Param {
+	params := []Param{
+		{Name: "port", Type: "int", Description: "port to send to, required unless the mode is ntp which defaults to 123"},
+		{Name: "mode", Type: "string", Description: "ntp queries the time and checks the clock offset; empty sends the payload"},
+		{Name: "payload", Type: "string", Description: "text sent"},
+		{Name: "payload_hex", Type: "string", Description: "bytes sent, written in hex, instead of the text payload"},
+		{Name: "expect_response", Type: "bool", Default: "false", Description: "report a problem when no response arrives; without it only a refused port is a problem"},
+		{Name: "expect", Type: "string", Description: "text the response must contain; the check waits for it"},
+		{Name: "expect_regex", Type: "string", Description: "regular expression the response must match; the check waits for it"},
+		{Name: "warning_offset_ms", Type: "int", Default: "100", Description: "ntp mode: clock offset in milliseconds above which the service is warning"},
+		{Name: "problem_offset_ms", Type: "int", Default: "1000", Description: "ntp mode: clock offset in milliseconds above which the service is problem"},
+		{Name: "timeout", Type: "int", Default: "5", Description: "seconds to wait for the response"},
+	}
+	return append(params, latencyParams()...)
+}
+
+func (udpChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := udpConfig{WarningOffsetMs: defaultNTPWarningOffsetMs, ProblemOffsetMs: defaultNTPProblemOffsetMs, Timeout: 5}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+
+	switch cfg.Mode {
+	case "":
+	case "ntp":
+		if cfg.Port == 0 {
+			cfg.Port = 123
+		}
+	default:
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("unknown mode %q", cfg.Mode), ErrorClass: ErrorClassConfig}
+	}
+
+	if cfg.Port <= 0 || cfg.Port > 65535 {
+		return models.CheckResult{Status: "problem", Message: "no valid port configured", ErrorClass: ErrorClassConfig}
+	}
+
+	payload, err := cfg.payload()
+	if err != nil {
+		return models.CheckResult{Status: "problem", Message: err.Error(), ErrorClass: ErrorClassConfig}
+	}
+
+	re, err := cfg.messageExpectation.compile()
+	if err != nil {
+		return models.CheckResult{Status: "problem", Message: err.Error(), ErrorClass: ErrorClassConfig}
+	}
+
+	address := net.JoinHostPort(hostAddress(h), strconv.Itoa(cfg.Port))
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", address)
+	if err != nil {
+		return models.CheckResult{
+			Status:     "problem",
+			Message:    fmt.Sprintf("%s - %s", address, "error connecting"),
+			ErrorClass: classifyError(err),
+			Details:    map[string]string{"error": err.Error()},
+		}
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if cfg.Mode == "ntp" {
+		return cfg.checkNTP(conn, address)
+	}
+
+	start := time.Now()
+	if _, err = conn.Write(payload); err != nil {
+		return models.CheckResult{
+			Status:     "problem",
+			Message:    fmt.Sprintf("%s - error sending", address),
+			Duration:   time.Since(start),
+			ErrorClass: classifyError(err),
+			Details:    map[string]string{"error": err.Error()},
+		}
+	}
+
+	r := models.CheckResult{
+		Status:  "healthy",
+		Details: map[string]string{"remote_addr": conn.RemoteAddr().String(), "sent_bytes": strconv.Itoa(len(payload))},
+		Metrics: map[string]float64{},
+	}
+
+	// a connected udp socket reads only datagrams from the port, and learns of
+	// a closed port from the icmp unreachable reply as a refused read
+	waitForResponse := cfg.ExpectResponse || cfg.Expect != "" || re != nil
+	buf := make([]byte, maxDatagram)
+	for {
+		n, err := conn.Read(buf)
+		r.Duration = time.Since(start)
+
+		var netErr net.Error
+		if err != nil && errors.As(err, &netErr) && netErr.Timeout() && !waitForResponse {
+			// the wait is no latency of the service
+			r.Duration = 0
+			r.Message = fmt.Sprintf("%s - sent %d bytes, no response", address, len(payload))
+			break
+		}
+		if err != nil {
+			r.Status = "problem"
+			r.Message = fmt.Sprintf("%s - no response: %s", address, err)
+			r.ErrorClass = classifyError(err)
+			if errors.As(err, &netErr) && netErr.Timeout() && r.Details["response"] != "" {
+				r.Message = fmt.Sprintf("%s - no expected response", address)
+				r.ErrorClass = ErrorClassAssertion
+			}
+			return r
+		}
+
+		response := string(buf[:n])
+		r.Details["response"] = printableDatagram(buf[:n])
+		if cfg.matches(response, re) {
+			r.Message = fmt.Sprintf("%s - response of %d bytes in %s", address, n, r.Duration.Round(time.Millisecond))
+			r.Metrics["response_ms"] = float64(r.Duration) / float64(time.Millisecond)
+			r.Metrics["response_bytes"] = float64(n)
+			break
+		}
+	}
+
+	cfg.apply(&r)
+	return r
+}
+
+// payload returns the bytes sent by the check
+func (cfg udpConfig) payload() ([]byte, error) {
+	if cfg.Payload != "" && cfg.PayloadHex != "" {
+		return nil, errors.New("set either payload or payload_hex")
+	}
+	if cfg.Mode == "ntp" && (cfg.Payload != "" || cfg.PayloadHex != "") {
+		return nil, errors.New("the ntp mode sends its own payload")
+	}
+
+	if cfg.PayloadHex != "" {
+		b, err := hex.DecodeString(strings.Join(strings.Fields(cfg.PayloadHex), ""))
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload_hex: %s", err)
+		}
+		return b, nil
+	}
+
+	return []byte(cfg.Payload), nil
+}
+
+// checkNTP sends an sntp client request and compares the server clock with the local one
+func (cfg udpConfig) checkNTP(conn net.Conn, address string) models.CheckResult {
+	// version 4, client mode
+	request := make([]byte, 48)
+	request[0] = 4<<3 | 3
+
+	t1 := time.Now()
+	binary.BigEndian.PutUint64(request[40:], ntpTimestamp(t1))
+	if _, err := conn.Write(request); err != nil {
+		return models.CheckResult{
+			Status:     "problem",
+			Message:    fmt.Sprintf("%s - error sending", address),
+			ErrorClass: classifyError(err),
+			Details:    map[string]string{"error": err.Error()},
+		}
+	}
+
+	response := make([]byte, maxDatagram)
+	var n int
+	var t4 time.Time
+	for {
+		var err error
+		n, err = conn.Read(response)
+		t4 = time.Now()
+		if err != nil {
+			return models.CheckResult{
+				Status:     "problem",
+				Message:    fmt.Sprintf("%s - no ntp response: %s", address, err),
+				Duration:   t4.Sub(t1),
+				ErrorClass: classifyError(err),
+			}
+		}
+
+		// a response repeats the transmit timestamp of its request as its origin
+		if n >= 48 && binary.BigEndian.Uint64(response[24:]) == binary.BigEndian.Uint64(request[40:]) {
+			break
+		}
+	}
+
+	mode := response[0] & 0x7
+	stratum := response[1]
+	if mode != 4 {
+		return models.CheckResult{
+			Status:     "problem",
+			Message:    fmt.Sprintf("%s - not an ntp server response, mode %d", address, mode),
+			Duration:   t4.Sub(t1),
+			ErrorClass: ErrorClassProtocol,
+		}
+	}
+	if stratum == 0 {
+		return models.CheckResult{
+			Status:     "problem",
+			Message:    fmt.Sprintf("%s - ntp server sent kiss code %s", address, strings.TrimRight(string(response[12:16]), "\x00")),
+			Duration:   t4.Sub(t1),
+			ErrorClass: ErrorClassProtocol,
+		}
+	}
+
+	t2 := ntpTime(binary.BigEndian.Uint64(response[32:]))
+	t3 := ntpTime(binary.BigEndian.Uint64(response[40:]))
+	offset := (t2.Sub(t1) + t3.Sub(t4)) / 2
+	delay := t4.Sub(t1) - t3.Sub(t2)
+
+	offsetMs := float64(offset) / float64(time.Millisecond)
+	r := models.CheckResult{
+		Status:   "healthy",
+		Message:  fmt.Sprintf("%s - stratum %d, clock offset %s", address, stratum, offset.Round(time.Microsecond)),
+		Duration: delay,
+		Details:  map[string]string{"server_time": t3.UTC().Format(time.RFC3339Nano), "stratum": strconv.Itoa(int(stratum))},
+		Metrics: map[string]float64{
+			"offset_ms": offsetMs,
+			"delay_ms":  float64(delay) / float64(time.Millisecond),
+			"stratum":   float64(stratum),
+		},
+	}
+
+	switch {
+	case cfg.ProblemOffsetMs > 0 && math.Abs(offsetMs) > float64(cfg.ProblemOffsetMs):
+		r.Status = "problem"
+		r.Message = fmt.Sprintf("%s (problem above %dms)", r.Message, cfg.ProblemOffsetMs)
+		r.ErrorClass = ErrorClassAssertion
+	case cfg.WarningOffsetMs > 0 && math.Abs(offsetMs) > float64(cfg.WarningOffsetMs):
+		r.Status = "warning"
+		r.Message = fmt.Sprintf("%s (warning above %dms)", r.Message, cfg.WarningOffsetMs)
+		r.ErrorClass = ErrorClassAssertion
+	}
+
+	cfg.apply(&r)
+	return r
+}
+
+// ntpTimestamp writes a time as an ntp timestamp, seconds since 1900 and a binary fraction
+func ntpTimestamp(t time.Time) uint64 {
+	seconds := uint64(t.Unix() + ntpEpochOffset)
+	fraction := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+	return seconds<<32 | fraction
+}
+
+// ntpTime reads an ntp timestamp
+func ntpTime(ts uint64) time.Time {
+	seconds := int64(ts>>32) - ntpEpochOffset
+	nanoseconds := int64((ts & 0xffffffff) * uint64(time.Second) >> 32)
+	return time.Unix(seconds, nanoseconds)
+}
+
+// printableDatagram returns a datagram as text, or as hex when it is binary
+func printableDatagram(b []byte) string {
+	if utf8.Valid(b) && strings.IndexFunc(string(b), func(r rune) bool { return !unicode.IsPrint(r) && !unicode.IsSpace(r) }) < 0 {
+		return string(b)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,165 @@
+package checks
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func TestNTPTimestamp(t *testing.T) {
+	tests := []struct {
+		name string
+		t    time.Time
+		want uint64
+	}{
+		{name: "unix epoch", t: time.Unix(0, 0), want: ntpEpochOffset << 32},
+		{name: "half a second", t: time.Unix(0, 500_000_000), want: ntpEpochOffset<<32 | 0x80000000},
+		{name: "quarter of a second", t: time.Unix(1, 250_000_000), want: (ntpEpochOffset+1)<<32 | 0x40000000},
+		{name: "ntp epoch", t: time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC), want: 0},
+		{name: "2024", t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), want: uint64(3913056000) << 32},
+	}
+
+	for _, tt := range tests {
+		if got := ntpTimestamp(tt.t); got != tt.want {
+			t.Errorf("%s: got %#x, expected %#x", tt.name, got, tt.want)
+		}
+		if got := ntpTime(tt.want); !got.Equal(tt.t) {
+			t.Errorf("%s: got time %s, expected %s", tt.name, got, tt.t)
+		}
+	}
+}
+
+func TestNTPTimeRoundTrip(t *testing.T) {
+	times := []time.Time{
+		time.Unix(0, 1),
+		time.Unix(1700000000, 123456789),
+		time.Unix(1700000000, 999999999),
+		time.Date(2035, 12, 31, 23, 59, 59, 987654321, time.UTC),
+	}
+
+	for _, want := range times {
+		// a fraction step is about 0.23ns, so a round trip may lose a nanosecond
+		got := ntpTime(ntpTimestamp(want))
+		if d := want.Sub(got); d < 0 || d > time.Nanosecond {
+			t.Errorf("%s: round trip gave %s, off by %s", want, got, d)
+		}
+	}
+}
+
+func TestPrintableDatagram(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		want string
+	}{
+		{in: []byte("PONG\r\n"), want: "PONG\r\n"},
+		{in: []byte("héllo"), want: "héllo"},
+		{in: []byte{0x00, 0x01, 0xff}, want: "0001ff"},
+		{in: []byte("ok\x00"), want: "6f6b00"},
+		{in: []byte{}, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := printableDatagram(tt.in); got != tt.want {
+			t.Errorf("%q: got %q, expected %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestUDPPayload(t *testing.T) {
+	tests := []struct {
+		cfg     udpConfig
+		want    []byte
+		wantErr bool
+	}{
+		{cfg: udpConfig{Payload: "ping"}, want: []byte("ping")},
+		{cfg: udpConfig{PayloadHex: "de ad\nbe ef"}, want: []byte{0xde, 0xad, 0xbe, 0xef}},
+		{cfg: udpConfig{}, want: []byte{}},
+		{cfg: udpConfig{PayloadHex: "zz"}, wantErr: true},
+		{cfg: udpConfig{Payload: "a", PayloadHex: "61"}, wantErr: true},
+		{cfg: udpConfig{Mode: "ntp", Payload: "a"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.cfg.payload()
+		if (err != nil) != tt.wantErr || !bytes.Equal(got, tt.want) {
+			t.Errorf("%+v: got (%x, %v), expected %x", tt.cfg, got, err, tt.want)
+		}
+	}
+}
+
+// ntpStub is a local stand-in ntp server whose clock is off by offset
+func ntpStub(t *testing.T, offset time.Duration, stratum byte) int {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < 48 {
+				continue
+			}
+			received := time.Now().Add(offset)
+
+			response := make([]byte, 48)
+			response[0] = 4<<3 | 4
+			response[1] = stratum
+			if stratum == 0 {
+				copy(response[12:16], "RATE")
+			}
+			copy(response[24:32], buf[40:48])
+			binary.BigEndian.PutUint64(response[32:], ntpTimestamp(received))
+			binary.BigEndian.PutUint64(response[40:], ntpTimestamp(time.Now().Add(offset)))
+			_, _ = conn.WriteTo(response, addr)
+		}
+	}()
+
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func TestUDPCheckNTP(t *testing.T) {
+	tests := []struct {
+		name       string
+		offset     time.Duration
+		stratum    byte
+		wantStatus string
+		wantClass  string
+	}{
+		{name: "in sync", stratum: 2, wantStatus: "healthy"},
+		{name: "drifting", offset: 500 * time.Millisecond, stratum: 2, wantStatus: "warning", wantClass: ErrorClassAssertion},
+		{name: "far behind", offset: -5 * time.Second, stratum: 2, wantStatus: "problem", wantClass: ErrorClassAssertion},
+		{name: "kiss of death", stratum: 0, wantStatus: "problem", wantClass: ErrorClassProtocol},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port := ntpStub(t, tt.offset, tt.stratum)
+			hs := models.HostService{Config: models.ServiceConfig{"mode": "ntp", "port": port}}
+			r := udpChecker{}.Check(context.Background(), models.Host{IP: "127.0.0.1"}, hs)
+
+			if r.Status != tt.wantStatus || r.ErrorClass != tt.wantClass {
+				t.Fatalf("got %s (%q): %s, expected %s (%q)", r.Status, r.ErrorClass, r.Message, tt.wantStatus, tt.wantClass)
+			}
+			if tt.stratum == 0 {
+				return
+			}
+			offset := time.Duration(r.Metrics["offset_ms"] * float64(time.Millisecond))
+			if d := offset - tt.offset; d < -50*time.Millisecond || d > 50*time.Millisecond {
+				t.Errorf("got offset %s, expected about %s", offset, tt.offset)
+			}
+		})
+	}
+}
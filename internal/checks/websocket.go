@@ -0,0 +1,265 @@
+package checks
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+	"golang.org/x/net/websocket"
+)
+
+func init() {
+	Register(websocketChecker{})
+}
+
+// websocketChecker opens a websocket and optionally waits for an expected message
+type websocketChecker struct{}
+
+// websocketConfig holds the parameters of a websocket check
+type websocketConfig struct {
+	latencyThresholds
+	messageExpectation
+	URL                string            `json:"url"`
+	Path               string            `json:"path"`
+	Headers            map[string]string `json:"headers"`
+	Origin             string            `json:"origin"`
+	Subprotocol        string            `json:"subprotocol"`
+	Send               string            `json:"send"`
+	InsecureSkipVerify bool              `json:"insecure_skip_verify"`
+	Timeout            int               `json:"timeout"`
+}
+
+// messageExpectation describes the message or event a realtime check waits for
+type messageExpectation struct {
+	Expect      string `json:"expect"`
+	ExpectRegex string `json:"expect_regex"`
+}
+
+func (websocketChecker) Kind() string { return "websocket" }
+
+func (websocketChecker) Name() string { return "WebSocket" }
+
+func (websocketChecker) Icon() string { return "fas fa-plug" }
+
+func (websocketChecker) Params() []Param {
+	params := []Param{
+		{Name: "url", Type: "string", Description: "ws:// or wss:// url, defaults to the host url with its scheme changed"},
+		{Name: "path", Type: "string", Description: `path and query requested instead of the one in the host url, e.g. "/app/key?protocol=7" for a pusher server`},
+		{Name: "headers", Type: "map", Description: "headers sent with the handshake"},
+		{Name: "origin", Type: "string", Description: "origin sent with the handshake, defaults to the host url"},
+		{Name: "subprotocol", Type: "string", Description: "subprotocol requested in the handshake"},
+		{Name: "send", Type: "string", Description: "text message sent after the handshake"},
+		{Name: "expect", Type: "string", Description: "text a received message must contain; the check waits for it"},
+		{Name: "expect_regex", Type: "string", Description: "regular expression a received message must match; the check waits for it"},
+		{Name: "insecure_skip_verify", Type: "bool", Default: "false", Description: "skip verification of the server certificate"},
+		{Name: "timeout", Type: "int", Default: "10", Description: "seconds for the handshake and the expected message"},
+	}
+	return append(params, latencyParams()...)
+}
+
+func (websocketChecker) Check(ctx context.Context, h models.Host, hs models.HostService) models.CheckResult {
+	cfg := websocketConfig{Timeout: 10}
+	if err := hs.Config.Decode(&cfg); err != nil {
+		return models.CheckResult{Status: "problem", Message: fmt.Sprintf("invalid config: %s", err), ErrorClass: ErrorClassConfig}
+	}
+
+	config, err := cfg.websocketConfig(h)
+	if err != nil {
+		return models.CheckResult{Status: "problem", Message: err.Error(), ErrorClass: ErrorClassConfig}
+	}
+	re, err := cfg.messageExpectation.compile()
+	if err != nil {
+		return models.CheckResult{Status: "problem", Message: err.Error(), ErrorClass: ErrorClassConfig}
+	}
+	target := config.Location.String()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	ws, tlsVersion, err := dialWebsocket(ctx, config)
+	handshake := time.Since(start)
+	if err != nil {
+		class := classifyError(err)
+		var protocolErr *websocket.ProtocolError
+		if errors.As(err, &protocolErr) {
+			class = ErrorClassProtocol
+		}
+
+		return models.CheckResult{
+			Status:     "problem",
+			Message:    fmt.Sprintf("%s - handshake failed: %s", target, err),
+			Duration:   handshake,
+			ErrorClass: class,
+		}
+	}
+	defer ws.Close()
+
+	r := models.CheckResult{
+		Status:     "healthy",
+		Message:    fmt.Sprintf("%s - connected in %s", target, handshake.Round(time.Millisecond)),
+		Duration:   handshake,
+		Details:    map[string]string{},
+		Metrics:    map[string]float64{"handshake_ms": float64(handshake) / float64(time.Millisecond)},
+		TLSVersion: tlsVersion,
+	}
+
+	if cfg.Send != "" {
+		if err = websocket.Message.Send(ws, cfg.Send); err != nil {
+			r.Status = "problem"
+			r.Message = fmt.Sprintf("%s - sending message: %s", target, err)
+			r.ErrorClass = classifyError(err)
+			return r
+		}
+	}
+
+	if cfg.Expect == "" && re == nil {
+		cfg.apply(&r)
+		return r
+	}
+
+	sent := time.Now()
+	for {
+		var msg string
+		if err = websocket.Message.Receive(ws, &msg); err != nil {
+			r.Status = "problem"
+			r.Message = fmt.Sprintf("%s - no expected message: %s", target, err)
+			r.ErrorClass = classifyError(err)
+			if r.ErrorClass == ErrorClassTimeout {
+				r.ErrorClass = ErrorClassAssertion
+			}
+			return r
+		}
+
+		if cfg.matches(msg, re) {
+			wait := time.Since(sent)
+			r.Duration += wait
+			r.Metrics["first_message_ms"] = float64(wait) / float64(time.Millisecond)
+			r.Details["message"] = msg
+			r.Message = fmt.Sprintf("%s - expected message after %s", target, r.Duration.Round(time.Millisecond))
+			break
+		}
+	}
+
+	cfg.apply(&r)
+	return r
+}
+
+// websocketConfig builds the handshake configuration of the check
+func (cfg websocketConfig) websocketConfig(h models.Host) (*websocket.Config, error) {
+	raw := cfg.URL
+	if raw == "" {
+		raw = h.URL
+		if !strings.Contains(raw, "://") {
+			raw = "http://" + raw
+		}
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	}
+	if cfg.URL == "" && cfg.Path != "" {
+		path, query, _ := strings.Cut(cfg.Path, "?")
+		u.Path = "/" + strings.TrimPrefix(path, "/")
+		u.RawPath = ""
+		u.RawQuery = query
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	origin := cfg.Origin
+	if origin == "" {
+		origin = (&url.URL{Scheme: strings.Replace(u.Scheme, "ws", "http", 1), Host: u.Host}).String()
+	}
+
+	config, err := websocket.NewConfig(u.String(), origin)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Subprotocol != "" {
+		config.Protocol = []string{cfg.Subprotocol}
+	}
+	for k, v := range cfg.Headers {
+		config.Header.Set(k, v)
+	}
+	config.TlsConfig = &tls.Config{ServerName: u.Hostname(), InsecureSkipVerify: cfg.InsecureSkipVerify}
+
+	return config, nil
+}
+
+// dialWebsocket connects and runs the handshake, both bounded by ctx, returning
+// the tls version negotiated for a wss url
+func dialWebsocket(ctx context.Context, config *websocket.Config) (*websocket.Conn, string, error) {
+	port := config.Location.Port()
+	if port == "" {
+		port = "80"
+		if config.Location.Scheme == "wss" {
+			port = "443"
+		}
+	}
+	address := net.JoinHostPort(config.Location.Hostname(), port)
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, "", err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	tlsVersion := ""
+	if config.Location.Scheme == "wss" {
+		tlsConn := tls.Client(conn, config.TlsConfig)
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, "", err
+		}
+		conn = tlsConn
+		tlsVersion = tls.VersionName(tlsConn.ConnectionState().Version)
+	}
+
+	ws, err := websocket.NewClient(config, conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, "", err
+	}
+
+	return ws, tlsVersion, nil
+}
+
+// compile returns the compiled expect_regex, nil when none is set
+func (e messageExpectation) compile() (*regexp.Regexp, error) {
+	if e.ExpectRegex == "" {
+		return nil, nil
+	}
+
+	re, err := regexp.Compile(e.ExpectRegex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expect_regex: %s", err)
+	}
+	return re, nil
+}
+
+// matches reports whether a message satisfies the expectation
+func (e messageExpectation) matches(msg string, re *regexp.Regexp) bool {
+	if e.Expect != "" && !strings.Contains(msg, e.Expect) {
+		return false
+	}
+	return re == nil || re.MatchString(msg)
+}
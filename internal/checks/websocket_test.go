@@ -0,0 +1,102 @@
+package checks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/namhuydao/vigilate/internal/models"
+	"golang.org/x/net/websocket"
+)
+
+func TestWebsocketCheck(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/echo", websocket.Handler(func(ws *websocket.Conn) {
+		var msg string
+		for websocket.Message.Receive(ws, &msg) == nil {
+			_ = websocket.Message.Send(ws, "echo: "+msg)
+		}
+	}))
+	mux.Handle("/ticker", websocket.Handler(func(ws *websocket.Conn) {
+		for _, msg := range []string{`{"event":"connected"}`, `{"event":"tick","n":1}`, `{"event":"tick","n":2}`} {
+			if websocket.Message.Send(ws, msg) != nil {
+				return
+			}
+		}
+		var msg string
+		_ = websocket.Message.Receive(ws, &msg)
+	}))
+	mux.Handle("/silent", websocket.Handler(func(ws *websocket.Conn) {
+		var msg string
+		_ = websocket.Message.Receive(ws, &msg)
+	}))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	base := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	tests := []struct {
+		name        string
+		config      models.ServiceConfig
+		wantStatus  string
+		wantClass   string
+		wantMessage string
+	}{
+		{
+			name:        "handshake only",
+			config:      models.ServiceConfig{"url": base + "/silent"},
+			wantStatus:  "healthy",
+			wantMessage: "connected in",
+		},
+		{
+			name:        "handshake failure",
+			config:      models.ServiceConfig{"url": base + "/missing"},
+			wantStatus:  "problem",
+			wantClass:   ErrorClassProtocol,
+			wantMessage: "handshake failed",
+		},
+		{
+			name:        "reply to the message sent",
+			config:      models.ServiceConfig{"url": base + "/echo", "send": "hello", "expect": "echo: hello"},
+			wantStatus:  "healthy",
+			wantMessage: "expected message after",
+		},
+		{
+			name:        "expected message after others",
+			config:      models.ServiceConfig{"url": base + "/ticker", "expect_regex": `"n":2`},
+			wantStatus:  "healthy",
+			wantMessage: "expected message after",
+		},
+		{
+			name:        "expected message never arrives",
+			config:      models.ServiceConfig{"url": base + "/silent", "expect": "hello", "timeout": 1},
+			wantStatus:  "problem",
+			wantClass:   ErrorClassAssertion,
+			wantMessage: "no expected message",
+		},
+		{
+			name:       "invalid expect_regex",
+			config:     models.ServiceConfig{"url": base + "/echo", "expect_regex": "("},
+			wantStatus: "problem",
+			wantClass:  ErrorClassConfig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := websocketChecker{}.Check(context.Background(), models.Host{}, models.HostService{Config: tt.config})
+
+			if r.Status != tt.wantStatus || r.ErrorClass != tt.wantClass {
+				t.Fatalf("got %s (%q): %s, expected %s (%q)", r.Status, r.ErrorClass, r.Message, tt.wantStatus, tt.wantClass)
+			}
+			if !strings.Contains(r.Message, tt.wantMessage) {
+				t.Errorf("message %q does not contain %q", r.Message, tt.wantMessage)
+			}
+			if strings.HasPrefix(tt.wantMessage, "expected") && r.Details["message"] == "" {
+				t.Errorf("expected message not recorded: %v", r.Details)
+			}
+		})
+	}
+}
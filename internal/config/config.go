@@ -2,6 +2,7 @@ package config
 
 import (
 	"html/template"
+	"sync"
 
 	"github.com/namhuydao/vigilate/internal/driver"
 	"github.com/namhuydao/vigilate/internal/models"
@@ -49,6 +50,42 @@ type AppConfig struct {
 	MailQueue     chan MailJob
 	Version       string
 	Identifier    string
+	PluginDir     string
 }
 
 var KnownRoutes = map[string]bool{}
+
+// preferenceMu guards PreferenceMap, which handlers update while checks and
+// workers read it
+var preferenceMu sync.RWMutex
+
+// Preference returns a site preference, or "" when it is not set
+func (a *AppConfig) Preference(name string) string {
+	preferenceMu.RLock()
+	defer preferenceMu.RUnlock()
+
+	return a.PreferenceMap[name]
+}
+
+// Preferences returns a copy of the site preferences, for templates
+func (a *AppConfig) Preferences() map[string]string {
+	preferenceMu.RLock()
+	defer preferenceMu.RUnlock()
+
+	prefs := make(map[string]string, len(a.PreferenceMap))
+	for k, v := range a.PreferenceMap {
+		prefs[k] = v
+	}
+	return prefs
+}
+
+// SetPreference sets a site preference
+func (a *AppConfig) SetPreference(name, value string) {
+	preferenceMu.Lock()
+	defer preferenceMu.Unlock()
+
+	if a.PreferenceMap == nil {
+		a.PreferenceMap = make(map[string]string)
+	}
+	a.PreferenceMap[name] = value
+}
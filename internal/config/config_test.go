@@ -0,0 +1,32 @@
+package config
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestPreferencesConcurrentAccess(t *testing.T) {
+	a := &AppConfig{PreferenceMap: map[string]string{"monitoring_live": "1"}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			a.SetPreference("ssl_expiry_warning_days", strconv.Itoa(i))
+		}(i)
+		go func() {
+			defer wg.Done()
+			_ = a.Preference("ssl_expiry_warning_days")
+			_ = a.Preferences()
+		}()
+	}
+	wg.Wait()
+
+	prefs := a.Preferences()
+	prefs["monitoring_live"] = "0"
+	if a.Preference("monitoring_live") != "1" {
+		t.Errorf("changing the copy changed the preferences")
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/namhuydao/vigilate/internal/checks"
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+// maxHeartbeatMessage is the longest msg kept from a heartbeat, which anyone with the push url can send
+const maxHeartbeatMessage = 1024
+
+// sweepInterval is how often host services with pushed results are checked for being overdue
+const sweepInterval = 30 * time.Second
+
+// Heartbeat records a ping of an external job on the push url of a heartbeat host
+// service. The optional status parameter reports the outcome of the job (success
+// or fail) and msg replaces the default message
+func (repo *DBRepo) Heartbeat(w http.ResponseWriter, r *http.Request) {
+	hs, err := repo.DB.GetHostServiceByPushToken(chi.URLParam(r, "token"))
+	if err != nil || hs.Service.Kind != "heartbeat" || hs.Active != 1 {
+		writeJsonResponse(w, http.StatusNotFound, JsonResp{Ok: false, Message: "unknown heartbeat"})
+		return
+	}
+
+	h, err := repo.DB.GetHostByID(hs.HostID)
+	if err != nil {
+		log.Println(err)
+		writeJsonResponse(w, http.StatusInternalServerError, JsonResp{Ok: false, Message: "Something went wrong"})
+		return
+	}
+
+	now := time.Now()
+	result := models.CheckResult{
+		HostServiceID: hs.ID,
+		Status:        "healthy",
+		Message:       "heartbeat received",
+		CheckedAt:     now,
+		Details:       map[string]string{"remote_addr": r.RemoteAddr},
+	}
+
+	switch strings.ToLower(r.FormValue("status")) {
+	case "", "success", "ok":
+	case "fail", "failure", "error":
+		result.Status = "problem"
+		result.Message = "job reported failure"
+		result.ErrorClass = checks.ErrorClassAssertion
+	default:
+		writeJsonResponse(w, http.StatusBadRequest, JsonResp{Ok: false, Message: "status must be success or fail"})
+		return
+	}
+
+	if msg := strings.TrimSpace(r.FormValue("msg")); msg != "" {
+		result.Message = truncateMessage(msg, maxHeartbeatMessage)
+	}
+
+	err = repo.DB.UpdateHostServiceLastPush(hs.ID, now.UTC())
+	if err != nil {
+		log.Println(err)
+	}
+
+	repo.recordPushedResult(h, hs, result)
+
+	writeJsonResponse(w, http.StatusOK, JsonResp{
+		Ok:            true,
+		Message:       result.Message,
+		ServiceId:     hs.ServiceID,
+		HostId:        hs.HostID,
+		HostServiceId: hs.ID,
+		OldStatus:     hs.Status,
+		NewStatus:     result.Status,
+		LastCheck:     now,
+	})
+}
+
+// StartSweeper flags host services with pushed results whose result is overdue; it never returns
+func (repo *DBRepo) StartSweeper() {
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		repo.sweepOverdue(time.Now())
+	}
+}
+
+// sweepOverdue records a problem for every push host service that went without a result for too long
+func (repo *DBRepo) sweepOverdue(now time.Time) {
+	if repo.App.Preference("monitoring_live") != "1" {
+		return
+	}
+
+	services, err := repo.DB.GetServicesToMonitor()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, hs := range services {
+		checker, ok := checks.Get(hs.Service.Kind)
+		if !ok {
+			continue
+		}
+
+		pushChecker, ok := checker.(checks.PushChecker)
+		if !ok {
+			continue
+		}
+
+		// an overdue service is recorded once, not on every sweep
+		result, late := pushChecker.Overdue(hs, now)
+		if !late || result.Status == hs.Status {
+			continue
+		}
+
+		h, err := repo.DB.GetHostByID(hs.HostID)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		result.HostServiceID = hs.ID
+		result.CheckedAt = now
+		repo.recordPushedResult(h, hs, result)
+	}
+}
@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"reflect"
 	"strconv"
+	"strings"
 
+	"github.com/namhuydao/vigilate/internal/checks"
 	"github.com/namhuydao/vigilate/internal/helpers"
 	"github.com/namhuydao/vigilate/internal/models"
 
@@ -47,11 +51,19 @@ func (repo *DBRepo) Host(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		h = host
+		repo.attachCheckResults(h.HostServices)
+	}
+
+	// parameters accepted by each check type, keyed by service kind
+	params := make(map[string][]checks.Param)
+	for _, c := range checks.All() {
+		params[c.Kind()] = c.Params()
 	}
 
 	td := helpers.TemplateData{
 		DataMap: map[string]any{
 			"host":      h,
+			"params":    params,
 			"PageTitle": "Host",
 			"PageUrl":   fmt.Sprintf("host/%d", h.ID),
 			"ActiveTab": activeTab,
@@ -87,12 +99,52 @@ func (repo *DBRepo) PostHost(w http.ResponseWriter, r *http.Request) {
 	active, _ := strconv.Atoi(r.Form.Get("active"))
 	h.Active = active
 
+	// read the check parameters of each host service, keeping the changed ones
+	var changed []models.HostService
+	for _, hs := range h.HostServices {
+		values, ok := r.Form[fmt.Sprintf("config_%d", hs.ID)]
+		if !ok {
+			continue
+		}
+
+		// an emptied textarea clears the config
+		raw := strings.TrimSpace(values[0])
+		if raw == "" {
+			raw = "{}"
+		}
+
+		var cfg models.ServiceConfig
+		err := json.Unmarshal([]byte(raw), &cfg)
+		if err != nil {
+			repo.App.Session.Put(r.Context(), "error", fmt.Sprintf("Invalid config for %s: %s", hs.Service.ServiceName, err))
+			http.Redirect(w, r, fmt.Sprintf("/admin/host/%d", h.ID), http.StatusSeeOther)
+			return
+		}
+		// secrets are shown masked, so a mask posted back keeps the stored value
+		cfg = cfg.Unmask(hs.Config)
+		if !reflect.DeepEqual(cfg, hs.Config) {
+			hs.Config = cfg
+			changed = append(changed, hs)
+		}
+	}
+
 	if id > 0 {
 		err := repo.DB.UpdateHost(h)
 		if err != nil {
 			log.Println(err)
 			return
 		}
+
+		// only the config is written, so results the scheduler saved since the
+		// form was loaded are kept
+		for _, hs := range changed {
+			err = repo.DB.UpdateHostServiceConfig(hs.ID, hs.Config)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			checks.ForgetRateSample(hs.ID)
+		}
 	} else {
 		newID, err := repo.DB.InsertHost(h)
 		if err != nil {
@@ -133,7 +185,7 @@ func (repo *DBRepo) ToggleServiceForHost(w http.ResponseWriter, r *http.Request)
 	h, _ := repo.DB.GetHostByID(hostID)
 
 	// add or remove from schedule
-	repo.PushStatusChangeEvent(h, hs, "pending")
+	repo.PushStatusChangeEvent(h, hs, "pending", 0)
 	repo.updateHostServiceStatusCount(hs, "pending", "")
 	if active == 1 {
 		repo.PushScheduleChangeEvent(hs, "pending")
@@ -34,12 +34,6 @@ func NewDBHandlers(db *driver.DB, a *config.AppConfig) *DBRepo {
 	}
 }
 
-const (
-	HTTP           = 1
-	HTTPS          = 2
-	SSLCertificate = 3
-)
-
 type JsonResp struct {
 	Ok            bool      `json:"ok"`
 	Message       string    `json:"message"`
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+// maxSubmittedResult is the largest request body accepted for a submitted result
+const maxSubmittedResult = 1 << 20
+
+// submittedResult is the body of a passive check result submitted over the api
+type submittedResult struct {
+	Status  string             `json:"status"`
+	Message string             `json:"message"`
+	Metrics map[string]float64 `json:"metrics"`
+	Details map[string]string  `json:"details"`
+}
+
+// SubmitCheckResult records a result computed by the monitored system itself for
+// a passive host service. The request is authenticated with the push token of
+// the host service as a bearer token
+func (repo *DBRepo) SubmitCheckResult(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		writeJsonResponse(w, http.StatusNotFound, JsonResp{Ok: false, Message: "unknown host service"})
+		return
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeJsonResponse(w, http.StatusUnauthorized, JsonResp{Ok: false, Message: "missing bearer token"})
+		return
+	}
+
+	hs, err := repo.DB.GetHostServiceByID(id)
+	if err != nil || subtle.ConstantTimeCompare([]byte(token), []byte(hs.PushToken)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeJsonResponse(w, http.StatusUnauthorized, JsonResp{Ok: false, Message: "invalid token"})
+		return
+	}
+
+	if hs.Service.Kind != "passive" || hs.Active != 1 {
+		writeJsonResponse(w, http.StatusConflict, JsonResp{Ok: false, Message: "host service does not accept submitted results"})
+		return
+	}
+
+	var submitted submittedResult
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSubmittedResult))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&submitted); err != nil {
+		writeJsonResponse(w, http.StatusBadRequest, JsonResp{Ok: false, Message: "invalid result: " + err.Error()})
+		return
+	}
+
+	switch submitted.Status {
+	case "healthy", "warning", "problem":
+	default:
+		writeJsonResponse(w, http.StatusBadRequest, JsonResp{Ok: false, Message: "status must be healthy, warning or problem"})
+		return
+	}
+
+	h, err := repo.DB.GetHostByID(hs.HostID)
+	if err != nil {
+		log.Println(err)
+		writeJsonResponse(w, http.StatusInternalServerError, JsonResp{Ok: false, Message: "Something went wrong"})
+		return
+	}
+
+	now := time.Now()
+	result := models.CheckResult{
+		HostServiceID: hs.ID,
+		Status:        submitted.Status,
+		Message:       strings.TrimSpace(submitted.Message),
+		CheckedAt:     now,
+		Metrics:       submitted.Metrics,
+		Details:       submitted.Details,
+	}
+	if result.Message == "" {
+		result.Message = "result submitted"
+	}
+
+	err = repo.DB.UpdateHostServiceLastPush(hs.ID, now.UTC())
+	if err != nil {
+		log.Println(err)
+	}
+
+	repo.recordPushedResult(h, hs, result)
+
+	writeJsonResponse(w, http.StatusOK, JsonResp{
+		Ok:            true,
+		Message:       result.Message,
+		ServiceId:     hs.ServiceID,
+		HostId:        hs.HostID,
+		HostServiceId: hs.ID,
+		OldStatus:     hs.Status,
+		NewStatus:     result.Status,
+		LastCheck:     now,
+	})
+}
@@ -1,16 +1,17 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
-	"github.com/namhuydao/vigilate/internal/certificateutils"
+	"github.com/namhuydao/vigilate/internal/checks"
 	"github.com/namhuydao/vigilate/internal/config"
+	"html"
 	"html/template"
-	"io"
 	"log"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/namhuydao/vigilate/internal/helpers"
 	"github.com/namhuydao/vigilate/internal/models"
@@ -19,6 +20,19 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
+// checkTimeout is the longest a single check may run
+const checkTimeout = 30 * time.Second
+
+// recentCheckResults is how many check results are shown per host service
+const recentCheckResults = 10
+
+// longest messages stored, the sizes of host_services.last_message and events.message;
+// check_results keeps the whole message
+const (
+	maxLastMessage  = 255
+	maxEventMessage = 512
+)
+
 func (repo *DBRepo) ScheduledCheck(hostServiceId int) {
 	hs, err := Repo.DB.GetHostServiceByID(hostServiceId)
 	if err != nil {
@@ -33,17 +47,17 @@ func (repo *DBRepo) ScheduledCheck(hostServiceId int) {
 	}
 
 	// test the service
-	newStatus, msg := Repo.testServiceForHost(host, hs)
+	result := Repo.testServiceForHost(host, hs)
 
-	if newStatus != hs.Status {
-		repo.updateHostServiceStatusCount(hs, newStatus, msg)
+	if result.Status != hs.Status {
+		repo.updateHostServiceStatusCount(hs, result.Status, result.Message)
 	}
 
 }
 
 func (repo *DBRepo) updateHostServiceStatusCount(hs models.HostService, newStatus, msg string) {
 	hs.Status = newStatus
-	hs.LastMessage = msg
+	hs.LastMessage = truncateMessage(msg, maxLastMessage)
 	hs.LastCheck = time.Now()
 
 	err := Repo.DB.UpdateHostService(hs)
@@ -94,14 +108,15 @@ func (repo *DBRepo) TestCheck(w http.ResponseWriter, req *http.Request) {
 		ok = false
 	}
 
-	newStatus, msg := repo.testServiceForHost(h, hs)
+	result := repo.testServiceForHost(h, hs)
+	newStatus, msg := result.Status, result.Message
 	event := models.Event{
 		EventType:     newStatus,
 		HostServiceID: hs.ID,
 		HostID:        h.ID,
 		ServiceName:   hs.Service.ServiceName,
 		HostName:      h.HostName,
-		Message:       msg,
+		Message:       truncateMessage(msg, maxEventMessage),
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 	}
@@ -112,11 +127,11 @@ func (repo *DBRepo) TestCheck(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if newStatus != hs.Status {
-		repo.PushStatusChangeEvent(h, hs, newStatus)
+		repo.PushStatusChangeEvent(h, hs, newStatus, result.Duration)
 	}
 
 	hs.Status = newStatus
-	hs.LastMessage = msg
+	hs.LastMessage = truncateMessage(msg, maxLastMessage)
 	hs.LastCheck = time.Now()
 	hs.UpdatedAt = time.Now()
 	err = repo.DB.UpdateHostService(hs)
@@ -145,72 +160,93 @@ func (repo *DBRepo) TestCheck(w http.ResponseWriter, req *http.Request) {
 	writeJsonResponse(w, http.StatusOK, resp)
 }
 
-func (repo *DBRepo) testServiceForHost(h models.Host, hs models.HostService) (string, string) {
-	var msg, newStatus string
-
-	switch hs.ServiceID {
-	case HTTP:
-		msg, newStatus = testHTTPForHost(h.URL)
-		break
-	case HTTPS:
-		msg, newStatus = testHTTPSForHost(h.URL)
-		break
-	case SSLCertificate:
-		msg, newStatus = testSSLForHost(h.URL)
+func (repo *DBRepo) testServiceForHost(h models.Host, hs models.HostService) models.CheckResult {
+	result := repo.runCheck(h, hs)
+	repo.processCheckResult(h, hs, result)
+
+	return result
+}
+
+// recordPushedResult runs a result that was pushed instead of polled through the
+// same pipeline as a scheduled check
+func (repo *DBRepo) recordPushedResult(h models.Host, hs models.HostService, result models.CheckResult) {
+	repo.processCheckResult(h, hs, result)
+
+	if result.Status != hs.Status {
+		repo.updateHostServiceStatusCount(hs, result.Status, result.Message)
+	}
+}
+
+// processCheckResult stores a check result and, when the status changed, records
+// the event, sends the notifications and broadcasts the change
+func (repo *DBRepo) processCheckResult(h models.Host, hs models.HostService, result models.CheckResult) {
+	msg, newStatus := result.Message, result.Status
+	latency := result.Duration.Round(time.Millisecond)
+
+	err := Repo.DB.InsertCheckResult(result)
+	if err != nil {
+		log.Println(err)
 	}
 
 	if hs.Status != newStatus {
-		repo.PushStatusChangeEvent(h, hs, newStatus)
+		repo.PushStatusChangeEvent(h, hs, newStatus, result.Duration)
+
+		// pushed results carry no latency; the message is cut before the latency is added
+		eventMsg := truncateMessage(msg, maxEventMessage)
+		if latency > 0 {
+			suffix := fmt.Sprintf(" (latency %s)", latency)
+			eventMsg = truncateMessage(msg, maxEventMessage-len(suffix)) + suffix
+		}
+
 		event := models.Event{
 			EventType:     newStatus,
 			HostServiceID: hs.ID,
 			HostID:        h.ID,
 			ServiceName:   hs.Service.ServiceName,
 			HostName:      h.HostName,
-			Message:       msg,
+			Message:       eventMsg,
 			CreatedAt:     time.Now(),
 			UpdatedAt:     time.Now(),
 		}
 
-		err := Repo.DB.InsertEvent(event)
+		err = Repo.DB.InsertEvent(event)
 		if err != nil {
 			log.Println(err)
 		}
 
-		if repo.App.PreferenceMap["notify_via_email"] == "1" {
+		if repo.App.Preference("notify_via_email") == "1" {
 			if hs.Status != "pending" {
 				mailMsg := config.MailData{
-					ToName:    repo.App.PreferenceMap["notify_name"],
-					ToAddress: repo.App.PreferenceMap["notify_email"],
+					ToName:    repo.App.Preference("notify_name"),
+					ToAddress: repo.App.Preference("notify_email"),
+					Content:   statusEmailContent(hs, newStatus, msg, latency),
 				}
 
 				if newStatus == "healthy" {
 					mailMsg.Subject = fmt.Sprintf("HEALTHY: service %s on %s", hs.Service.ServiceName, hs.HostName)
-					mailMsg.Content = template.HTML(fmt.Sprintf(`<p>Service %s on %s reported healthy status</p>
-						<p><strong>Message received: %s</strong></p>
-						`, hs.Service.ServiceName, hs.HostName, msg))
 				} else if newStatus == "problem" {
 					mailMsg.Subject = fmt.Sprintf("PROBLEM: service %s on %s", hs.Service.ServiceName, hs.HostName)
-					mailMsg.Content = template.HTML(fmt.Sprintf(`<p>Service %s on %s reported problem</p>
-						<p><strong>Message received: %s</strong></p>
-						`, hs.Service.ServiceName, hs.HostName, msg))
 				} else if newStatus == "warning" {
-
+					mailMsg.Subject = fmt.Sprintf("WARNING: service %s on %s", hs.Service.ServiceName, hs.HostName)
 				}
 				helpers.SendEmail(mailMsg)
 			}
 		}
 
-		if repo.App.PreferenceMap["notify_via_sms"] == "1" {
-			to := repo.App.PreferenceMap["sms_notify_number"]
+		if repo.App.Preference("notify_via_sms") == "1" {
+			to := repo.App.Preference("sms_notify_number")
 			smsMessage := ""
+			smsLatency := ""
+			if latency > 0 {
+				smsLatency = fmt.Sprintf(" (%s)", latency)
+			}
 
 			if newStatus == "healthy" {
-				smsMessage = fmt.Sprintf("HEALTHY: service %s on %s", hs.Service.ServiceName, hs.HostName)
+				smsMessage = fmt.Sprintf("HEALTHY: service %s on %s%s", hs.Service.ServiceName, hs.HostName, smsLatency)
 			} else if newStatus == "problem" {
-				smsMessage = fmt.Sprintf("PROBLEM: service %s on %s", hs.Service.ServiceName, hs.HostName)
+				smsMessage = fmt.Sprintf("PROBLEM: service %s on %s%s", hs.Service.ServiceName, hs.HostName, smsLatency)
 			} else if newStatus == "warning" {
-				smsMessage = fmt.Sprintf("WARNING: service %s on %s", hs.Service.ServiceName, hs.HostName)
+				smsMessage = fmt.Sprintf("WARNING: service %s on %s%s", hs.Service.ServiceName, hs.HostName, smsLatency)
 			}
 
 			err = sms.SendTextTwilio(to, smsMessage, repo.App)
@@ -222,11 +258,81 @@ func (repo *DBRepo) testServiceForHost(h models.Host, hs models.HostService) (st
 	}
 
 	repo.PushScheduleChangeEvent(hs, newStatus)
+}
+
+// statusEmailContent returns the body of the email sent when a host service changes
+// status; the message may come from outside, so everything but the markup is escaped
+func statusEmailContent(hs models.HostService, newStatus, msg string, latency time.Duration) template.HTML {
+	reported := map[string]string{
+		"healthy": "reported healthy status",
+		"problem": "reported problem",
+		"warning": "reported warning",
+	}[newStatus]
+
+	// pushed results carry no latency
+	latencyLine := ""
+	if latency > 0 {
+		latencyLine = fmt.Sprintf("<p>Latency: %s</p>", latency)
+	}
+
+	return template.HTML(fmt.Sprintf(`<p>Service %s on %s %s</p>
+		<p><strong>Message received: %s</strong></p>
+		%s
+		`, html.EscapeString(hs.Service.ServiceName), html.EscapeString(hs.HostName), reported, html.EscapeString(msg), latencyLine))
+}
+
+// truncateMessage shortens a message to at most max characters so it fits its column
+func truncateMessage(msg string, max int) string {
+	if utf8.RuneCountInString(msg) <= max {
+		return msg
+	}
+
+	return string([]rune(msg)[:max])
+}
+
+// attachCheckResults loads the latest check results onto each host service
+func (repo *DBRepo) attachCheckResults(services []models.HostService) {
+	for i := range services {
+		results, err := repo.DB.GetCheckResultsByHostServiceID(services[i].ID, recentCheckResults)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		services[i].CheckResults = results
+	}
+}
+
+// runCheck runs the checker registered for the kind of the host service
+func (repo *DBRepo) runCheck(h models.Host, hs models.HostService) models.CheckResult {
+	start := time.Now()
+
+	var result models.CheckResult
+	checker, ok := checks.Get(hs.Service.Kind)
+	if ok {
+		ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+		result = checks.Run(ctx, checker, h, hs)
+		cancel()
+	} else {
+		result = models.CheckResult{
+			Status:     "problem",
+			Message:    fmt.Sprintf("no checker registered for service kind %q", hs.Service.Kind),
+			ErrorClass: checks.ErrorClassConfig,
+		}
+	}
 
-	return newStatus, msg
+	result.HostServiceID = hs.ID
+	if result.Duration == 0 {
+		result.Duration = time.Since(start)
+	}
+	if result.CheckedAt.IsZero() {
+		result.CheckedAt = start
+	}
+
+	return result
 }
 
-func (repo *DBRepo) PushStatusChangeEvent(h models.Host, hs models.HostService, newStatus string) {
+// PushStatusChangeEvent broadcasts a status change; latency is left out of the payload when zero
+func (repo *DBRepo) PushStatusChangeEvent(h models.Host, hs models.HostService, newStatus string, latency time.Duration) {
 	count, err := repo.DB.GetServiceStatusCounts(hs.Status)
 	if err != nil {
 		log.Println(err)
@@ -240,6 +346,10 @@ func (repo *DBRepo) PushStatusChangeEvent(h models.Host, hs models.HostService,
 	data["icon"] = hs.Service.Icon
 	data["status"] = newStatus
 	data["message"] = fmt.Sprintf("%s on %s reports %s", hs.Service.ServiceName, h.HostName, newStatus)
+	if latency > 0 {
+		data["latency"] = latency.Round(time.Millisecond).String()
+		data["message"] = fmt.Sprintf("%s on %s reports %s (%s)", hs.Service.ServiceName, h.HostName, newStatus, data["latency"])
+	}
 	data["last_check"] = time.Now().Format("2006-01-02 15:04:05")
 	data["total_new_status"] = strconv.Itoa(count - 1)
 
@@ -268,109 +378,8 @@ func (repo *DBRepo) PushScheduleChangeEvent(hs models.HostService, newStatus str
 	repo.BroadcastMessage("public-channel", "schedule-changed-event", data)
 }
 
-func testHTTPForHost(url string) (string, string) {
-	if strings.HasSuffix(url, "/") {
-		strings.TrimSuffix(url, "/")
-	}
-
-	url = strings.Replace(url, "https://", "http://", -1)
-	resp, err := http.Get(url)
-	if err != nil {
-		return fmt.Sprintf("%s - %s", url, "error connecting"), "problem"
-	}
-
-	defer func(Body io.ReadCloser) {
-		err = Body.Close()
-		if err != nil {
-
-		}
-	}(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Sprintf("%s - %s", url, resp.Status), "problem"
-	}
-	return fmt.Sprintf("%s - %s", url, resp.Status), "healthy"
-}
-
-func testHTTPSForHost(url string) (string, string) {
-	if strings.HasSuffix(url, "/") {
-		strings.TrimSuffix(url, "/")
-	}
-
-	url = strings.Replace(url, "http://", "https://", -1)
-	resp, err := http.Get(url)
-	if err != nil {
-		return fmt.Sprintf("%s - %s", url, "error connecting"), "problem"
-	}
-
-	defer func(Body io.ReadCloser) {
-		err = Body.Close()
-		if err != nil {
-
-		}
-	}(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Sprintf("%s - %s", url, resp.Status), "problem"
-	}
-	return fmt.Sprintf("%s - %s", url, resp.Status), "healthy"
-}
-func scanHost(hostname string, certDetailsChannel chan certificateutils.CertificateDetails, errorsChannel chan error) {
-	res, err := certificateutils.GetCertificateDetails(hostname, 10)
-	if err != nil {
-		errorsChannel <- err
-	} else {
-		certDetailsChannel <- res
-	}
-	return
-}
-
-func testSSLForHost(url string) (string, string) {
-	if strings.HasPrefix(url, "https://") {
-		url = strings.Replace(url, "https://", "", -1)
-	}
-
-	if strings.HasPrefix(url, "http://") {
-		url = strings.Replace(url, "http://", "", -1)
-	}
-
-	var certDetailsChannel chan certificateutils.CertificateDetails
-	var errorsChannel chan error
-	certDetailsChannel = make(chan certificateutils.CertificateDetails, 1)
-	errorsChannel = make(chan error, 1)
-
-	var msg, newStatus string
-	scanHost(url, certDetailsChannel, errorsChannel)
-
-	for i, certDetailsInQueue := 0, len(certDetailsChannel); i < certDetailsInQueue; i++ {
-		certDetails := <-certDetailsChannel
-		certificateutils.CheckExpirationStatus(&certDetails, 30)
-
-		if certDetails.Expired {
-			// cert expired
-			msg = certDetails.Hostname + " has expired!"
-
-		} else if certDetails.ExpiringSoon {
-			// cert expiring sono
-			if certDetails.DaysUntilExpiration < 7 {
-				msg = certDetails.Hostname + " expiring in " + strconv.Itoa(certDetails.DaysUntilExpiration) + " days"
-				newStatus = "problem"
-			} else {
-				msg = certDetails.Hostname + " expiring in " + strconv.Itoa(certDetails.DaysUntilExpiration) + " days"
-				newStatus = "warning"
-			}
-		} else {
-			// cert okay
-			msg = certDetails.Hostname + " expiring in " + strconv.Itoa(certDetails.DaysUntilExpiration) + " days"
-			newStatus = "healthy"
-		}
-	}
-
-	return msg, newStatus
-}
-
 func (repo *DBRepo) AddToMonitorMap(hs models.HostService) {
-	if repo.App.PreferenceMap["monitoring_live"] == "1" {
+	if repo.App.Preference("monitoring_live") == "1" && !checks.IsPush(hs.Service.Kind) {
 		var j job
 		j.HostServiceId = hs.ID
 		scheduleId, err := repo.App.Scheduler.AddJob(fmt.Sprintf("@every %d%s", hs.ScheduleNumber, hs.ScheduleUnit), j)
@@ -393,7 +402,8 @@ func (repo *DBRepo) AddToMonitorMap(hs models.HostService) {
 }
 
 func (repo *DBRepo) RemoveFromMonitorMap(hs models.HostService) {
-	if repo.App.PreferenceMap["monitoring_live"] == "1" {
+	checks.ForgetRateSample(hs.ID)
+	if repo.App.Preference("monitoring_live") == "1" {
 		repo.App.Scheduler.Remove(repo.App.MonitorMap[hs.ID])
 		data := make(map[string]string)
 		data["host_service_id"] = strconv.Itoa(hs.ID)
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+func TestStatusEmailContentEscapesMessage(t *testing.T) {
+	hs := models.HostService{HostName: "db <1>", Service: models.Services{ServiceName: "Passive"}}
+
+	tests := []struct {
+		name    string
+		status  string
+		msg     string
+		latency time.Duration
+		want    []string
+	}{
+		{
+			name:   "submitted result",
+			status: "problem",
+			msg:    `<script>alert("x")</script> disk full`,
+			want:   []string{"reported problem", "&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt; disk full", "db &lt;1&gt;"},
+		},
+		{
+			name:    "checked result",
+			status:  "healthy",
+			msg:     "200 OK & fast",
+			latency: 12 * time.Millisecond,
+			want:    []string{"reported healthy status", "200 OK &amp; fast", "<p>Latency: 12ms</p>"},
+		},
+	}
+
+	for _, tt := range tests {
+		content := string(statusEmailContent(hs, tt.status, tt.msg, tt.latency))
+
+		if strings.Contains(content, "<script>") || strings.Contains(content, "db <1>") {
+			t.Errorf("%s: unescaped input in %q", tt.name, content)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(content, want) {
+				t.Errorf("%s: %q does not contain %q", tt.name, content, want)
+			}
+		}
+		if tt.latency == 0 && strings.Contains(content, "Latency") {
+			t.Errorf("%s: latency shown for a result without one", tt.name)
+		}
+	}
+}
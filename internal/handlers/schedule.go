@@ -47,6 +47,10 @@ func (repo *DBRepo) ListEntries(w http.ResponseWriter, r *http.Request) {
 				item.LastRunFromHS = hs.LastCheck
 				item.Host = hs.HostName
 				item.Service = hs.Service.ServiceName
+				item.CheckResults, err = repo.DB.GetCheckResultsByHostServiceID(k, recentCheckResults)
+				if err != nil {
+					log.Println(err)
+				}
 				items = append(items, item)
 
 			} else {
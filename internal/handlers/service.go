@@ -17,6 +17,7 @@ func (repo *DBRepo) AllServices(w http.ResponseWriter, r *http.Request) {
 		log.Println(err)
 		return
 	}
+	repo.attachCheckResults(services)
 
 	td := helpers.TemplateData{
 		DataMap: map[string]any{
@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/namhuydao/vigilate/internal/checks"
 	"github.com/namhuydao/vigilate/internal/helpers"
 )
 
@@ -40,12 +44,21 @@ func (repo *DBRepo) PostSettings(w http.ResponseWriter, r *http.Request) {
 	prefMap["notify_via_sms"] = r.Form.Get("notify_via_sms")
 	prefMap["notify_via_email"] = r.Form.Get("notify_via_email")
 	prefMap["sms_notify_number"] = r.Form.Get("sms_notify_number")
+	prefMap["ssl_expiry_warning_days"] = r.Form.Get("ssl_expiry_warning_days")
+	prefMap["ssl_expiry_problem_days"] = r.Form.Get("ssl_expiry_problem_days")
 
 	if r.Form.Get("sms_enabled") == "0" {
 		prefMap["notify_via_sms"] = "0"
 	}
 
-	err := repo.DB.InsertOrUpdateSitePreferences(prefMap)
+	err := validateExpiryDays(prefMap["ssl_expiry_warning_days"], prefMap["ssl_expiry_problem_days"])
+	if err != nil {
+		app.Session.Put(r.Context(), "error", err.Error())
+		http.Redirect(w, r, "/admin/settings", http.StatusSeeOther)
+		return
+	}
+
+	err = repo.DB.InsertOrUpdateSitePreferences(prefMap)
 	if err != nil {
 		log.Println(err)
 		ClientError(w, r, http.StatusBadRequest)
@@ -54,7 +67,7 @@ func (repo *DBRepo) PostSettings(w http.ResponseWriter, r *http.Request) {
 
 	// update setup config
 	for k, v := range prefMap {
-		app.PreferenceMap[k] = v
+		app.SetPreference(k, v)
 	}
 
 	app.Session.Put(r.Context(), "flash", "Changes saved")
@@ -84,7 +97,7 @@ func (repo *DBRepo) SetSystemPref(w http.ResponseWriter, r *http.Request) {
 		resp.Message = err.Error()
 	}
 
-	repo.App.PreferenceMap["monitoring_live"] = requestBody.PrefValue
+	repo.App.SetPreference("monitoring_live", requestBody.PrefValue)
 
 	writeJsonResponse(w, http.StatusOK, resp)
 }
@@ -103,12 +116,12 @@ func (repo *DBRepo) ToggleMonitoring(w http.ResponseWriter, r *http.Request) {
 
 	if requestBody.Enabled == 1 {
 		// start monitoring
-		repo.App.PreferenceMap["monitoring_live"] = "1"
+		repo.App.SetPreference("monitoring_live", "1")
 		repo.StartMonitoring()
 		repo.App.Scheduler.Start()
 	} else {
 		// stop monitoring
-		repo.App.PreferenceMap["monitoring_live"] = "0"
+		repo.App.SetPreference("monitoring_live", "0")
 
 		// remove all items in map from schedule
 		for _, x := range repo.App.MonitorMap {
@@ -117,6 +130,7 @@ func (repo *DBRepo) ToggleMonitoring(w http.ResponseWriter, r *http.Request) {
 
 		// empty the map
 		for k := range repo.App.MonitorMap {
+			checks.ForgetRateSample(k)
 			delete(repo.App.MonitorMap, k)
 		}
 
@@ -141,3 +155,20 @@ func (repo *DBRepo) ToggleMonitoring(w http.ResponseWriter, r *http.Request) {
 
 	writeJsonResponse(w, http.StatusOK, resp)
 }
+
+// validateExpiryDays returns an error unless the certificate expiry thresholds are
+// whole numbers of days and the problem threshold is not above the warning one
+func validateExpiryDays(warning, problem string) error {
+	warningDays, err := strconv.Atoi(strings.TrimSpace(warning))
+	if err != nil || warningDays < 0 {
+		return fmt.Errorf("SSL expiry warning days must be a whole number of days, not %q", warning)
+	}
+	problemDays, err := strconv.Atoi(strings.TrimSpace(problem))
+	if err != nil || problemDays < 0 {
+		return fmt.Errorf("SSL expiry problem days must be a whole number of days, not %q", problem)
+	}
+	if problemDays > warningDays {
+		return fmt.Errorf("SSL expiry problem days (%d) must not be more than the warning days (%d)", problemDays, warningDays)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import "testing"
+
+func TestValidateExpiryDays(t *testing.T) {
+	tests := []struct {
+		warning, problem string
+		wantErr          bool
+	}{
+		{warning: "30", problem: "7"},
+		{warning: "14", problem: "14"},
+		{warning: " 30 ", problem: "0"},
+		{warning: "thirty", problem: "7", wantErr: true},
+		{warning: "30", problem: "", wantErr: true},
+		{warning: "-1", problem: "0", wantErr: true},
+		{warning: "30", problem: "-7", wantErr: true},
+		{warning: "7", problem: "30", wantErr: true},
+		{warning: "30.5", problem: "7", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := validateExpiryDays(tt.warning, tt.problem)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("warning %q, problem %q: got %v, expected an error %v", tt.warning, tt.problem, err, tt.wantErr)
+		}
+	}
+}
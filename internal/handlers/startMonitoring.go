@@ -5,6 +5,8 @@ import (
 	"log"
 	"strconv"
 	"time"
+
+	"github.com/namhuydao/vigilate/internal/checks"
 )
 
 type job struct {
@@ -30,6 +32,11 @@ func (repo *DBRepo) StartMonitoring() {
 	}
 
 	for _, service := range servicesToMonitor {
+		// push services report their own results and are watched by the sweeper
+		if checks.IsPush(service.Service.Kind) {
+			continue
+		}
+
 		var schedule string
 		if service.ScheduleUnit == "d" {
 			schedule = fmt.Sprintf("@every %d%s", service.ScheduleNumber*24, "h")
@@ -94,7 +94,7 @@ func DateAfterY1(t time.Time) bool {
 func AddDefaultData(td TemplateData, r *http.Request) TemplateData {
 	td.CSRFToken = nosurf.Token(r)
 	td.IsAuthenticated = IsAuthenticated(r)
-	td.PreferenceMap = app.PreferenceMap
+	td.PreferenceMap = app.Preferences()
 	// if logged in, store user id in template data
 	if td.IsAuthenticated {
 		u := app.Session.Get(r.Context(), "user").(models.User)
@@ -180,8 +180,8 @@ func HxRender(w http.ResponseWriter, r *http.Request, templateName string, data
 // SendEmail sends an email
 func SendEmail(mailMessage config.MailData) {
 	if mailMessage.FromAddress == "" {
-		mailMessage.FromAddress = app.PreferenceMap["smtp_from_email"]
-		mailMessage.FromName = app.PreferenceMap["smtp_from_name"]
+		mailMessage.FromAddress = app.Preference("smtp_from_email")
+		mailMessage.FromName = app.Preference("smtp_from_name")
 	}
 
 	job := config.MailJob{MailMessage: mailMessage}
@@ -98,6 +98,8 @@ func NoSurf(next http.Handler) http.Handler {
 
 	csrfHandler.ExemptPath("/pusher/auth")
 	csrfHandler.ExemptPath("/pusher/hook")
+	csrfHandler.ExemptGlob("/heartbeat/*")
+	csrfHandler.ExemptRegexp("^/api/")
 
 	csrfHandler.SetBaseCookie(http.Cookie{
 		HttpOnly: true,
@@ -114,7 +116,7 @@ func NoSurf(next http.Handler) http.Handler {
 func CheckRemember(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if !helpers.IsAuthenticated(r) {
-			cookie, err := r.Cookie(fmt.Sprintf("_%s_gowatcher_remember", app.PreferenceMap["identifier"]))
+			cookie, err := r.Cookie(fmt.Sprintf("_%s_gowatcher_remember", app.Preference("identifier")))
 			if err != nil {
 				next.ServeHTTP(w, r)
 			} else {
@@ -149,7 +151,7 @@ func CheckRemember(next http.Handler) http.Handler {
 			}
 		} else {
 			// they are logged in, but make sure that the remember token has not been revoked
-			cookie, err := r.Cookie(fmt.Sprintf("_%s_gowatcher_remember", app.PreferenceMap["identifier"]))
+			cookie, err := r.Cookie(fmt.Sprintf("_%s_gowatcher_remember", app.Preference("identifier")))
 			if err != nil {
 				// no cookie
 				next.ServeHTTP(w, r)
@@ -181,7 +183,7 @@ func deleteRememberCookie(w http.ResponseWriter, r *http.Request) {
 	_ = app.Session.RenewToken(r.Context())
 	// delete the cookie
 	newCookie := http.Cookie{
-		Name:     fmt.Sprintf("_%s_gowatcher_remember", app.PreferenceMap["identifier"]),
+		Name:     fmt.Sprintf("_%s_gowatcher_remember", app.Preference("identifier")),
 		Value:    "",
 		Path:     "/",
 		Expires:  time.Now().Add(-100 * time.Hour),
@@ -6,6 +6,32 @@ INSERT INTO public.preferences (id, name, preference, created_at, updated_at) VA
 INSERT INTO public.preferences (id, name, preference, created_at, updated_at) VALUES (2, 'check_interval_amount', '3', '2020-06-26 07:49:33.648011 +00:00', '2020-06-26 07:49:33.648011 +00:00');
 INSERT INTO public.preferences (id, name, preference, created_at, updated_at) VALUES (3, 'check_interval_unit', 'm', '2020-06-26 07:49:33.648011 +00:00', '2020-06-26 07:49:33.648011 +00:00');
 INSERT INTO public.preferences (id, name, preference, created_at, updated_at) VALUES (4, 'notify_via_email', '0', '2020-06-26 07:49:33.648011 +00:00', '2020-06-26 07:49:33.648011 +00:00');
+INSERT INTO public.preferences (id, name, preference, created_at, updated_at) VALUES (5, 'ssl_expiry_warning_days', '30', '2024-04-11 02:20:08.000000 +00:00', '2024-04-11 02:20:08.000000 +00:00');
+INSERT INTO public.preferences (id, name, preference, created_at, updated_at) VALUES (6, 'ssl_expiry_problem_days', '7', '2024-04-11 02:20:08.000000 +00:00', '2024-04-11 02:20:08.000000 +00:00');
+SELECT setval('preferences_id_seq', (SELECT MAX(id) FROM public.preferences));
 
-INSERT INTO public.services (id, service_name, active, icon, created_at, updated_at) VALUES (1, 'HTTP', 1, 'fas fa-server', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (1, 'HTTP', 1, 'fas fa-server', 'http', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (2, 'HTTPS', 1, 'fas fa-server', 'https', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (3, 'SSL Certificate', 1, 'fas fa-lock', 'ssl', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (4, 'TCP', 1, 'fas fa-network-wired', 'tcp', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (5, 'Certificate File', 1, 'fas fa-file-contract', 'certfile', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (6, 'DNS', 1, 'fas fa-sitemap', 'dns', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (7, 'Ping', 1, 'fas fa-satellite-dish', 'ping', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (8, 'Command', 1, 'fas fa-terminal', 'command', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (9, 'Heartbeat', 1, 'fas fa-heartbeat', 'heartbeat', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (10, 'Passive', 1, 'fas fa-inbox', 'passive', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (11, 'PostgreSQL', 1, 'fas fa-database', 'postgres', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (12, 'MySQL', 1, 'fas fa-database', 'mysql', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (13, 'Redis', 1, 'fas fa-layer-group', 'redis', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (14, 'gRPC', 1, 'fas fa-project-diagram', 'grpc', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (15, 'SMTP', 1, 'fas fa-paper-plane', 'smtp', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (16, 'IMAP', 1, 'fas fa-inbox', 'imap', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (17, 'POP3', 1, 'fas fa-envelope-open', 'pop3', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (18, 'Transaction', 1, 'fas fa-route', 'transaction', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (19, 'WebSocket', 1, 'fas fa-plug', 'websocket', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (20, 'Server-Sent Events', 1, 'fas fa-stream', 'sse', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (21, 'SSH', 1, 'fas fa-key', 'ssh', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (22, 'Domain Expiry', 1, 'fas fa-globe', 'domain', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (23, 'Prometheus Metric', 1, 'fas fa-chart-line', 'prometheus', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
+INSERT INTO public.services (id, service_name, active, icon, kind, created_at, updated_at) VALUES (24, 'UDP', 1, 'fas fa-exchange-alt', 'udp', '2024-04-11 02:20:08.000000', '2024-04-11 02:20:09.000000');
 
@@ -59,6 +59,9 @@ CREATE TABLE services
     service_name VARCHAR(255)      NOT NULL,
     active       INTEGER DEFAULT 1 NOT NULL,
     icon         VARCHAR(255)      NOT NULL,
+    kind         VARCHAR(255)      NOT NULL
+        CONSTRAINT services_kind_uindex
+            UNIQUE,
     created_at   TIMESTAMP         NOT NULL,
     updated_at   TIMESTAMP         NOT NULL
 );
@@ -82,7 +85,12 @@ CREATE TABLE host_services
     created_at      TIMESTAMP                                                               NOT NULL,
     updated_at      TIMESTAMP                                                               NOT NULL,
     status          VARCHAR(255) DEFAULT 'pending'::CHARACTER VARYING                       NOT NULL,
-    last_message    VARCHAR(255) DEFAULT ''::CHARACTER VARYING                              NOT NULL
+    last_message    VARCHAR(255) DEFAULT ''::CHARACTER VARYING                              NOT NULL,
+    config          JSONB        DEFAULT '{}'::JSONB                                        NOT NULL,
+    push_token      VARCHAR(64)  DEFAULT gen_random_uuid()::TEXT                            NOT NULL
+        CONSTRAINT host_services_push_token_key
+            UNIQUE,
+    last_push       TIMESTAMP    DEFAULT '0001-01-01 00:00:01'::TIMESTAMP WITHOUT TIME ZONE NOT NULL
 );
 
 CREATE TABLE events
@@ -99,3 +107,26 @@ CREATE TABLE events
     updated_at      TIMESTAMP    NOT NULL
 );
 
+
+CREATE TABLE check_results
+(
+    id              SERIAL
+        PRIMARY KEY,
+    host_service_id INTEGER                            NOT NULL
+        CONSTRAINT check_results_host_services_id_fk
+            REFERENCES host_services
+            ON UPDATE CASCADE ON DELETE CASCADE,
+    status          VARCHAR(255)                       NOT NULL,
+    message         TEXT             DEFAULT ''        NOT NULL,
+    duration_ms     DOUBLE PRECISION DEFAULT 0         NOT NULL,
+    status_code     INTEGER          DEFAULT 0         NOT NULL,
+    tls_version     VARCHAR(255)     DEFAULT ''        NOT NULL,
+    error_class     VARCHAR(255)     DEFAULT ''        NOT NULL,
+    details         JSONB            DEFAULT '{}'      NOT NULL,
+    metrics         JSONB            DEFAULT '{}'      NOT NULL,
+    checked_at      TIMESTAMP                          NOT NULL,
+    created_at      TIMESTAMP                          NOT NULL
+);
+
+CREATE INDEX check_results_host_service_id_checked_at_index
+    ON check_results (host_service_id, checked_at DESC);
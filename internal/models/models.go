@@ -1,8 +1,12 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/pusher/pusher-http-go"
@@ -79,6 +83,7 @@ type Services struct {
 	ServiceName string
 	Active      int
 	Icon        string
+	Kind        string
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
@@ -94,10 +99,170 @@ type HostService struct {
 	Status         string
 	LastCheck      time.Time
 	LastMessage    string
+	Config         ServiceConfig
+	PushToken      string
+	LastPush       time.Time
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
 	Service        Services
 	HostName       string
+	CheckResults   []CheckResult
+}
+
+// ServiceConfig holds the check parameters of a host service, stored as JSON
+type ServiceConfig map[string]any
+
+// Value implements driver.Valuer so a ServiceConfig can be written to a jsonb column
+func (c ServiceConfig) Value() (driver.Value, error) {
+	if c == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(c)
+}
+
+// Scan implements sql.Scanner so a ServiceConfig can be read from a jsonb column
+func (c *ServiceConfig) Scan(src any) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		*c = ServiceConfig{}
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("models: cannot scan %T into ServiceConfig", src)
+	}
+
+	cfg := ServiceConfig{}
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return err
+	}
+	*c = cfg
+	return nil
+}
+
+// Decode unmarshals the config into v, which is usually a struct with json tags
+// that already holds the defaults of a check type
+func (c ServiceConfig) Decode(v any) error {
+	if len(c) == 0 {
+		return nil
+	}
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// String returns the config as indented JSON, for use in forms
+func (c ServiceConfig) String() string {
+	if len(c) == 0 {
+		return "{}"
+	}
+
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
+
+// SecretMask is shown instead of the secret values of a config
+const SecretMask = "********"
+
+// secretKeys are the config keys holding credentials, at any depth and in any case
+var secretKeys = map[string]bool{
+	"password":      true,
+	"bearer_token":  true,
+	"authorization": true,
+}
+
+// Masked returns a copy of the config with the values of secret keys replaced by
+// SecretMask, for showing in forms
+func (c ServiceConfig) Masked() ServiceConfig {
+	masked, _ := maskSecrets(map[string]any(c)).(map[string]any)
+	return masked
+}
+
+// Unmask returns the config with each SecretMask value replaced by the value at
+// the same place in stored, so a masked form posted back keeps its secrets
+func (c ServiceConfig) Unmask(stored ServiceConfig) ServiceConfig {
+	unmasked, _ := unmaskSecrets(map[string]any(c), map[string]any(stored)).(map[string]any)
+	return unmasked
+}
+
+// maskSecrets copies v, masking the non empty string values of secret keys
+func maskSecrets(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, value := range v {
+			if s, ok := value.(string); ok && s != "" && secretKeys[strings.ToLower(k)] {
+				out[k] = SecretMask
+				continue
+			}
+			out[k] = maskSecrets(value)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, value := range v {
+			out[i] = maskSecrets(value)
+		}
+		return out
+	}
+	return v
+}
+
+// unmaskSecrets copies v, taking each SecretMask value from stored; a mask with
+// nothing stored in its place is dropped
+func unmaskSecrets(v, stored any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		storedMap, _ := stored.(map[string]any)
+		out := make(map[string]any, len(v))
+		for k, value := range v {
+			if value == SecretMask {
+				if s, ok := storedMap[k].(string); ok {
+					out[k] = s
+				}
+				continue
+			}
+			out[k] = unmaskSecrets(value, storedMap[k])
+		}
+		return out
+	case []any:
+		storedSlice, _ := stored.([]any)
+		out := make([]any, len(v))
+		for i, value := range v {
+			var s any
+			if i < len(storedSlice) {
+				s = storedSlice[i]
+			}
+			out[i] = unmaskSecrets(value, s)
+		}
+		return out
+	}
+	return v
+}
+
+// CheckResult holds the outcome of a single check of a host service
+type CheckResult struct {
+	ID            int
+	HostServiceID int
+	Status        string
+	Message       string
+	Duration      time.Duration
+	StatusCode    int
+	TLSVersion    string
+	ErrorClass    string
+	Details       map[string]string
+	Metrics       map[string]float64
+	CheckedAt     time.Time
+	CreatedAt     time.Time
 }
 
 // Schedule model
@@ -110,6 +275,7 @@ type Schedule struct {
 	LastRunFromHS time.Time
 	HostServiceID int
 	ScheduleText  string
+	CheckResults  []CheckResult
 }
 
 // Event model
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestServiceConfigMasked(t *testing.T) {
+	cfg := ServiceConfig{
+		"user":         "monitor",
+		"password":     "s3cret",
+		"bearer_token": "b34rer",
+		"basic_auth":   map[string]any{"username": "u", "password": "p"},
+		"headers":      map[string]any{"Authorization": "Bearer b34rer", "Accept": "text/plain"},
+		"steps":        []any{map[string]any{"url": "/login", "password": "st3p"}},
+		"password_env": "VIGILATE_CHECK_DB",
+		"timeout":      float64(5),
+	}
+
+	masked := cfg.Masked()
+	if s := masked.String(); strings.Contains(s, "s3cret") || strings.Contains(s, "b34rer") || strings.Contains(s, `"p"`) || strings.Contains(s, "st3p") {
+		t.Errorf("secrets left in the masked config: %s", s)
+	}
+	if masked["user"] != "monitor" || masked["password_env"] != "VIGILATE_CHECK_DB" || masked["timeout"] != float64(5) {
+		t.Errorf("masking changed other values: %v", masked)
+	}
+	if cfg["password"] != "s3cret" {
+		t.Errorf("masking changed the config itself")
+	}
+
+	if unmasked := masked.Unmask(cfg); !reflect.DeepEqual(unmasked, cfg) {
+		t.Errorf("got %v, expected the stored config %v", unmasked, cfg)
+	}
+}
+
+func TestServiceConfigUnmask(t *testing.T) {
+	stored := ServiceConfig{"user": "monitor", "password": "old", "basic_auth": map[string]any{"username": "u", "password": "p"}}
+
+	tests := []struct {
+		name   string
+		posted ServiceConfig
+		want   ServiceConfig
+	}{
+		{
+			name:   "new password",
+			posted: ServiceConfig{"user": "monitor", "password": "new"},
+			want:   ServiceConfig{"user": "monitor", "password": "new"},
+		},
+		{
+			name:   "password removed",
+			posted: ServiceConfig{"user": "monitor"},
+			want:   ServiceConfig{"user": "monitor"},
+		},
+		{
+			name:   "nested mask kept",
+			posted: ServiceConfig{"basic_auth": map[string]any{"username": "v", "password": SecretMask}},
+			want:   ServiceConfig{"basic_auth": map[string]any{"username": "v", "password": "p"}},
+		},
+		{
+			name:   "mask without a stored value is dropped",
+			posted: ServiceConfig{"bearer_token": SecretMask},
+			want:   ServiceConfig{},
+		},
+		{
+			name:   "cleared",
+			posted: ServiceConfig{},
+			want:   ServiceConfig{},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := tt.posted.Unmask(stored); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, expected %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package postgresRepo
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
+
+// InsertCheckResult inserts a check result into the database
+func (m *postgresDBRepo) InsertCheckResult(cr models.CheckResult) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	details, err := json.Marshal(cr.Details)
+	if err != nil {
+		return err
+	}
+
+	metrics, err := json.Marshal(cr.Metrics)
+	if err != nil {
+		return err
+	}
+
+	stmt := `
+		INSERT INTO check_results (host_service_id, status, message, duration_ms, status_code,
+			tls_version, error_class, details, metrics, checked_at, created_at)
+		VALUES
+			($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+
+	_, err = m.DB.ExecContext(ctx, stmt,
+		cr.HostServiceID,
+		cr.Status,
+		cr.Message,
+		float64(cr.Duration)/float64(time.Millisecond),
+		cr.StatusCode,
+		cr.TLSVersion,
+		cr.ErrorClass,
+		details,
+		metrics,
+		cr.CheckedAt,
+		time.Now(),
+	)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetCheckResultsByHostServiceID gets the latest check results for a host service, newest first
+func (m *postgresDBRepo) GetCheckResultsByHostServiceID(hostServiceID, limit int) ([]models.CheckResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT
+			id, host_service_id, status, message, duration_ms, status_code,
+			tls_version, error_class, details, metrics, checked_at, created_at
+		FROM
+			check_results
+		WHERE
+			host_service_id = $1
+		ORDER BY
+			checked_at DESC
+		LIMIT $2`
+
+	var results []models.CheckResult
+
+	rows, err := m.DB.QueryContext(ctx, query, hostServiceID, limit)
+	if err != nil {
+		return results, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cr models.CheckResult
+		var durationMs float64
+		var details, metrics []byte
+
+		err = rows.Scan(
+			&cr.ID,
+			&cr.HostServiceID,
+			&cr.Status,
+			&cr.Message,
+			&durationMs,
+			&cr.StatusCode,
+			&cr.TLSVersion,
+			&cr.ErrorClass,
+			&details,
+			&metrics,
+			&cr.CheckedAt,
+			&cr.CreatedAt,
+		)
+		if err != nil {
+			log.Println(err)
+			return results, err
+		}
+
+		cr.Duration = time.Duration(durationMs * float64(time.Millisecond))
+
+		if err = json.Unmarshal(details, &cr.Details); err != nil {
+			return results, err
+		}
+		if err = json.Unmarshal(metrics, &cr.Metrics); err != nil {
+			return results, err
+		}
+
+		results = append(results, cr)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Println(err)
+		return results, err
+	}
+
+	return results, nil
+}
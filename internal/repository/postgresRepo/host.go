@@ -36,6 +36,12 @@ func (m *postgresDBRepo) InsertHost(h models.Host) (int, error) {
 		return newID, err
 	}
 
+	// keep any config that came in with the host services
+	configs := make(map[int]models.ServiceConfig)
+	for _, hs := range h.HostServices {
+		configs[hs.ServiceID] = hs.Config
+	}
+
 	// add host services and set to inactive
 	query = `SELECT id FROM services`
 	serviceRows, err := m.DB.QueryContext(ctx, query)
@@ -56,9 +62,9 @@ func (m *postgresDBRepo) InsertHost(h models.Host) (int, error) {
 		stmt := `
 			INSERT INTO host_services
 		    	(host_id, service_id, active, schedule_number, schedule_unit,
-				status, created_at, updated_at) VALUES ($1, $2, 0, 3, 'm', 'pending', $3, $4)`
+				status, config, created_at, updated_at) VALUES ($1, $2, 0, 3, 'm', 'pending', $3, $4, $5)`
 
-		_, err = m.DB.ExecContext(ctx, stmt, newID, svcID, time.Now(), time.Now())
+		_, err = m.DB.ExecContext(ctx, stmt, newID, svcID, configs[svcID], time.Now(), time.Now())
 		if err != nil {
 			return newID, err
 		}
@@ -105,7 +111,7 @@ func (m *postgresDBRepo) GetHostByID(id int) (models.Host, error) {
 			SELECT
 				hs.id, hs.host_id, hs.service_id, hs.active, hs.schedule_number, hs.schedule_unit,
 				hs.last_check, hs.status, hs.created_at, hs.updated_at,
-				s.id, s.service_name, s.active, s.icon, s.created_at, s.updated_at, hs.last_message
+				s.id, s.service_name, s.active, s.icon, s.kind, s.created_at, s.updated_at, hs.last_message, hs.config, hs.push_token, hs.last_push
 			FROM
 				host_services hs
 				LEFT JOIN services s ON (s.id = hs.service_id)
@@ -138,9 +144,13 @@ func (m *postgresDBRepo) GetHostByID(id int) (models.Host, error) {
 			&hs.Service.ServiceName,
 			&hs.Service.Active,
 			&hs.Service.Icon,
+			&hs.Service.Kind,
 			&hs.Service.CreatedAt,
 			&hs.Service.UpdatedAt,
 			&hs.LastMessage,
+			&hs.Config,
+			&hs.PushToken,
+			&hs.LastPush,
 		)
 		if err != nil {
 			log.Println(err)
@@ -236,7 +246,7 @@ func (m *postgresDBRepo) AllHosts() ([]models.Host, error) {
 			SELECT
 				hs.id, hs.host_id, hs.service_id, hs.active, hs.schedule_number, hs.schedule_unit,
 				hs.last_check, hs.status, hs.created_at, hs.updated_at,
-				s.id, s.service_name, s.active, s.icon, s.created_at, s.updated_at, hs.last_message
+				s.id, s.service_name, s.active, s.icon, s.kind, s.created_at, s.updated_at, hs.last_message, hs.config, hs.push_token, hs.last_push
 			FROM
 				host_services hs
 				LEFT JOIN services s ON (s.id = hs.service_id)
@@ -268,9 +278,13 @@ func (m *postgresDBRepo) AllHosts() ([]models.Host, error) {
 				&hs.Service.ServiceName,
 				&hs.Service.Active,
 				&hs.Service.Icon,
+				&hs.Service.Kind,
 				&hs.Service.CreatedAt,
 				&hs.Service.UpdatedAt,
 				&hs.LastMessage,
+				&hs.Config,
+				&hs.PushToken,
+				&hs.LastPush,
 			)
 			if err != nil {
 				log.Println(err)
@@ -74,9 +74,10 @@ func (m *postgresDBRepo) UpdateHostService(hs models.HostService) error {
    			host_services SET
    				host_id = $1, service_id = $2, active = $3,
 				  	schedule_number = $4, schedule_unit = $5,
-				  	last_check = $6, status = $7, updated_at = $8, last_message = $9
+				  	last_check = $6, status = $7, updated_at = $8, last_message = $9,
+				  	config = $10
 				WHERE
-					id = $10`
+					id = $11`
 
 	_, err := m.DB.ExecContext(ctx, stmt,
 		hs.HostID,
@@ -88,6 +89,7 @@ func (m *postgresDBRepo) UpdateHostService(hs models.HostService) error {
 		hs.Status,
 		hs.UpdatedAt,
 		hs.LastMessage,
+		hs.Config,
 		hs.ID,
 	)
 	if err != nil {
@@ -96,6 +98,21 @@ func (m *postgresDBRepo) UpdateHostService(hs models.HostService) error {
 	return nil
 }
 
+// UpdateHostServiceConfig updates only the check parameters of a host service,
+// leaving the status written by the scheduler alone
+func (m *postgresDBRepo) UpdateHostServiceConfig(id int, config models.ServiceConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `UPDATE host_services SET config = $1, updated_at = $2 WHERE id = $3`
+
+	_, err := m.DB.ExecContext(ctx, stmt, config, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 // GetServicesByStatus returns all active services with a given status
 func (m *postgresDBRepo) GetServicesByStatus(status string) ([]models.HostService, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -105,7 +122,7 @@ func (m *postgresDBRepo) GetServicesByStatus(status string) ([]models.HostServic
 		SELECT
 			hs.id, hs.host_id, hs.service_id, hs.active, hs.schedule_number, hs.schedule_unit,
 			hs.last_check, hs.status, hs.created_at, hs.updated_at,
-			h.host_name, s.service_name, hs.last_message
+			h.host_name, s.service_name, hs.last_message, hs.config, hs.push_token, hs.last_push
 		FROM
 			host_services hs
 			LEFT JOIN hosts h ON (hs.host_id = h.id)
@@ -141,6 +158,9 @@ func (m *postgresDBRepo) GetServicesByStatus(status string) ([]models.HostServic
 			&h.HostName,
 			&h.Service.ServiceName,
 			&h.LastMessage,
+			&h.Config,
+			&h.PushToken,
+			&h.LastPush,
 		)
 		if err != nil {
 			return nil, err
@@ -160,8 +180,8 @@ func (m *postgresDBRepo) GetHostServiceByID(id int) (models.HostService, error)
 	query := `
 		SELECT hs.id, hs.host_id, hs.service_id, hs.active, hs.schedule_number,
 			hs.schedule_unit, hs.last_check, hs.status, hs.created_at, hs.updated_at,
-			s.id, s.service_name, s.active, s.icon, s.created_at, s.updated_at, h.host_name,
-		    hs.last_message
+			s.id, s.service_name, s.active, s.icon, s.kind, s.created_at, s.updated_at, h.host_name,
+		    hs.last_message, hs.config, hs.push_token, hs.last_push
 
 		FROM host_services hs
 		LEFT JOIN services s ON (hs.service_id = s.id)
@@ -189,10 +209,14 @@ func (m *postgresDBRepo) GetHostServiceByID(id int) (models.HostService, error)
 		&hs.Service.ServiceName,
 		&hs.Service.Active,
 		&hs.Service.Icon,
+		&hs.Service.Kind,
 		&hs.Service.CreatedAt,
 		&hs.Service.UpdatedAt,
 		&hs.HostName,
 		&hs.LastMessage,
+		&hs.Config,
+		&hs.PushToken,
+		&hs.LastPush,
 	)
 
 	if err != nil {
@@ -203,6 +227,75 @@ func (m *postgresDBRepo) GetHostServiceByID(id int) (models.HostService, error)
 	return hs, nil
 }
 
+// GetHostServiceByPushToken gets a host service by the secret token results are pushed with
+func (m *postgresDBRepo) GetHostServiceByPushToken(token string) (models.HostService, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT hs.id, hs.host_id, hs.service_id, hs.active, hs.schedule_number,
+			hs.schedule_unit, hs.last_check, hs.status, hs.created_at, hs.updated_at,
+			s.id, s.service_name, s.active, s.icon, s.kind, s.created_at, s.updated_at, h.host_name,
+		    hs.last_message, hs.config, hs.push_token, hs.last_push
+
+		FROM host_services hs
+		LEFT JOIN services s ON (hs.service_id = s.id)
+		LEFT JOIN hosts h ON (hs.host_id = h.id)
+
+		WHERE hs.push_token = $1
+`
+
+	var hs models.HostService
+
+	row := m.DB.QueryRowContext(ctx, query, token)
+
+	err := row.Scan(
+		&hs.ID,
+		&hs.HostID,
+		&hs.ServiceID,
+		&hs.Active,
+		&hs.ScheduleNumber,
+		&hs.ScheduleUnit,
+		&hs.LastCheck,
+		&hs.Status,
+		&hs.CreatedAt,
+		&hs.UpdatedAt,
+		&hs.Service.ID,
+		&hs.Service.ServiceName,
+		&hs.Service.Active,
+		&hs.Service.Icon,
+		&hs.Service.Kind,
+		&hs.Service.CreatedAt,
+		&hs.Service.UpdatedAt,
+		&hs.HostName,
+		&hs.LastMessage,
+		&hs.Config,
+		&hs.PushToken,
+		&hs.LastPush,
+	)
+
+	if err != nil {
+		return hs, err
+	}
+
+	return hs, nil
+}
+
+// UpdateHostServiceLastPush records when a result was last pushed for a host service
+func (m *postgresDBRepo) UpdateHostServiceLastPush(id int, lastPush time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	stmt := `UPDATE host_services SET last_push = $1 WHERE id = $2`
+
+	_, err := m.DB.ExecContext(ctx, stmt, lastPush, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // GetServicesToMonitor gets all host services we want to monitor
 func (m *postgresDBRepo) GetServicesToMonitor() ([]models.HostService, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -211,8 +304,8 @@ func (m *postgresDBRepo) GetServicesToMonitor() ([]models.HostService, error) {
 	query := `
 		SELECT hs.id, hs.host_id, hs.service_id, hs.active, hs.schedule_number,
 			hs.schedule_unit, hs.last_check, hs.status, hs.created_at, hs.updated_at,
-			s.id, s.service_name, s.active, s.icon, s.created_at, s.updated_at,
-			h.host_name, hs.last_message
+			s.id, s.service_name, s.active, s.icon, s.kind, s.created_at, s.updated_at,
+			h.host_name, hs.last_message, hs.config, hs.push_token, hs.last_push
 		FROM
 		     host_services hs
 			LEFT JOIN services s ON (hs.service_id = s.id)
@@ -246,10 +339,14 @@ func (m *postgresDBRepo) GetServicesToMonitor() ([]models.HostService, error) {
 			&h.Service.ServiceName,
 			&h.Service.Active,
 			&h.Service.Icon,
+			&h.Service.Kind,
 			&h.Service.CreatedAt,
 			&h.Service.UpdatedAt,
 			&h.HostName,
 			&h.LastMessage,
+			&h.Config,
+			&h.PushToken,
+			&h.LastPush,
 		)
 		if err != nil {
 			log.Println(err)
@@ -269,8 +366,8 @@ func (m *postgresDBRepo) GetHostServiceByHostIDServiceID(hostID, serviceID int)
 	query := `
 		SELECT hs.id, hs.host_id, hs.service_id, hs.active, hs.schedule_number,
 			hs.schedule_unit, hs.last_check, hs.status, hs.created_at, hs.updated_at,
-			s.id, s.service_name, s.active, s.icon, s.created_at, s.updated_at, h.host_name,
-		    hs.last_message
+			s.id, s.service_name, s.active, s.icon, s.kind, s.created_at, s.updated_at, h.host_name,
+		    hs.last_message, hs.config, hs.push_token, hs.last_push
 
 		FROM host_services hs
 		LEFT JOIN services s ON (hs.service_id = s.id)
@@ -298,10 +395,14 @@ func (m *postgresDBRepo) GetHostServiceByHostIDServiceID(hostID, serviceID int)
 		&hs.Service.ServiceName,
 		&hs.Service.Active,
 		&hs.Service.Icon,
+		&hs.Service.Kind,
 		&hs.Service.CreatedAt,
 		&hs.Service.UpdatedAt,
 		&hs.HostName,
 		&hs.LastMessage,
+		&hs.Config,
+		&hs.PushToken,
+		&hs.LastPush,
 	)
 
 	if err != nil {
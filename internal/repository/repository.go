@@ -1,6 +1,10 @@
 package repository
 
-import "github.com/namhuydao/vigilate/internal/models"
+import (
+	"time"
+
+	"github.com/namhuydao/vigilate/internal/models"
+)
 
 // DatabaseRepo is the database repository
 type DatabaseRepo interface {
@@ -36,8 +40,15 @@ type DatabaseRepo interface {
 	GetCountHostServiceActive(id int) (int, error)
 	GetHostServiceByID(id int) (models.HostService, error)
 	GetHostServiceByHostIDServiceID(hostID, serviceID int) (models.HostService, error)
+	GetHostServiceByPushToken(token string) (models.HostService, error)
+	UpdateHostServiceLastPush(id int, lastPush time.Time) error
 	UpdateHostService(hs models.HostService) error
+	UpdateHostServiceConfig(id int, config models.ServiceConfig) error
 	GetServicesToMonitor() ([]models.HostService, error)
 	GetAllEvents() ([]models.Event, error)
 	InsertEvent(e models.Event) error
+
+	// Check results
+	InsertCheckResult(cr models.CheckResult) error
+	GetCheckResultsByHostServiceID(hostServiceID, limit int) ([]models.CheckResult, error)
 }
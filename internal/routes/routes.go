@@ -25,6 +25,13 @@ func Routes() http.Handler {
 
 	mux.Get("/user/logout", handlers.Repo.Logout)
 
+	// heartbeat pings of push services, authenticated by the token in the url
+	mux.Get("/heartbeat/{token}", handlers.Repo.Heartbeat)
+	mux.Post("/heartbeat/{token}", handlers.Repo.Heartbeat)
+
+	// results of passive services, authenticated by the push token as a bearer token
+	mux.Post("/api/host-services/{id}/results", handlers.Repo.SubmitCheckResult)
+
 	// our pusher routes
 	mux.With(middleware.Auth).Route("/pusher", func(mux chi.Router) {
 		mux.Post("/auth", handlers.Repo.PusherAuth)
@@ -13,6 +13,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/namhuydao/vigilate/internal/checks"
 	"github.com/namhuydao/vigilate/internal/config"
 	"github.com/namhuydao/vigilate/internal/driver"
 	"github.com/namhuydao/vigilate/internal/handlers"
@@ -72,6 +73,7 @@ func InitApp() (string, error) {
 	if err != nil {
 		pusherSecure = false
 	}
+	pluginDir := os.Getenv("PLUGIN_DIR")
 
 	log.Println("Connecting to database....")
 	dsnString := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s timezone=UTC connect_timeout=5",
@@ -116,6 +118,10 @@ func InitApp() (string, error) {
 	dispatcher := NewDispatcher(mailQueue, maxJobMaxWorkers)
 	dispatcher.run()
 
+	if pluginDir != "" {
+		log.Println("Running command check plugins from", pluginDir)
+	}
+
 	// define application configuration
 	a := config.AppConfig{
 		DB:           db,
@@ -126,12 +132,14 @@ func InitApp() (string, error) {
 		MailQueue:    mailQueue,
 		Version:      vigilateVersion,
 		Identifier:   identifier,
+		PluginDir:    pluginDir,
 	}
 
 	app = a
 
 	repo = handlers.NewDBHandlers(db, &app)
 	handlers.NewHandlers(repo, &app)
+	checks.NewChecks(&app)
 
 	log.Println("Getting preferences...")
 	preferenceMap = make(map[string]string)
@@ -176,11 +184,13 @@ func InitApp() (string, error) {
 
 	app.Scheduler = scheduler
 
-	if app.PreferenceMap["monitoring_live"] == "1" {
+	if app.Preference("monitoring_live") == "1" {
 		go handlers.Repo.StartMonitoring()
 		app.Scheduler.Start()
 	}
 
+	go handlers.Repo.StartSweeper()
+
 	helpers.NewHelpers(&app)
 	middleware.NewMiddleware(&app, db)
 
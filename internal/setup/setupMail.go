@@ -112,7 +112,7 @@ func (w Worker) processMailQueueJob(mailMessage config.MailData) {
 		Content:       mailMessage.Content,
 		FromName:      mailMessage.FromName,
 		From:          mailMessage.FromAddress,
-		PreferenceMap: app.PreferenceMap,
+		PreferenceMap: app.Preferences(),
 		IntMap:        mailMessage.IntMap,
 		StringMap:     mailMessage.StringMap,
 		FloatMap:      mailMessage.FloatMap,
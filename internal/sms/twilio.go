@@ -14,14 +14,14 @@ import (
 
 // SendTextTwilio sends a text using Twilio service
 func SendTextTwilio(to, msg string, app *config.AppConfig) error {
-	secret := app.PreferenceMap["twilio_auth_token"]
-	key := app.PreferenceMap["twilio_sid"]
+	secret := app.Preference("twilio_auth_token")
+	key := app.Preference("twilio_sid")
 
 	urlStr := fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json", key)
 
 	msgData := url.Values{}
 	msgData.Set("To", to)
-	msgData.Set("From", app.PreferenceMap["twilio_phone_number"])
+	msgData.Set("From", app.Preference("twilio_phone_number"))
 	msgData.Set("Body", msg)
 
 	msgDataReader := *strings.NewReader(msgData.Encode())